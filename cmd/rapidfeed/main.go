@@ -4,9 +4,11 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/http"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/mcp"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
 
@@ -28,6 +30,37 @@ func init() {
 	utils.SecretKey = utils.GetStringEnv("SECRET_KEY", "strong-secretkey")
 	utils.RegisterAllowed = utils.GetBoolEnv("REGISTRATION_ALLOWED", true)
 	utils.DBPath = utils.GetStringEnv("DB_PATH", "./feeds.db")
+	utils.FetchConcurrency = utils.GetIntEnv("FETCH_CONCURRENCY", 8)
+	utils.FetchHostConcurrency = utils.GetIntEnv("FETCH_HOST_CONCURRENCY", 2)
+	utils.FetchTimeout = time.Duration(utils.GetIntEnv("FETCH_TIMEOUT_SECONDS", 30)) * time.Second
+	utils.FetchUserAgent = utils.GetStringEnv("FETCH_USER_AGENT",
+		"RapidFeed/"+Version+" (+https://github.com/GeorgijGrigoriev/RapidFeed)")
+	utils.FetchMaxResponseMB = utils.GetIntEnv("FETCH_MAX_RESPONSE_MB", 20)
+	utils.FetchProxy = utils.GetStringEnv("FETCH_PROXY", "")
+	utils.FetchCABundle = utils.GetStringEnv("FETCH_CA_BUNDLE", "")
+	utils.FetchHeadersFile = utils.GetStringEnv("FETCH_HEADERS_FILE", "")
+	utils.FeedDeadAfterFailures = utils.GetIntEnv("FEED_DEAD_AFTER_FAILURES", 10)
+	utils.RetentionMaxAgeDays = utils.GetIntEnv("RETENTION_MAX_AGE_DAYS", 0)
+	utils.RetentionMaxItemsPerFeed = utils.GetIntEnv("RETENTION_MAX_ITEMS_PER_FEED", 0)
+	utils.PruneInterval = time.Duration(utils.GetIntEnv("PRUNE_INTERVAL_MINUTES", 360)) * time.Minute
+	utils.ImageProxyEnabled = utils.GetBoolEnv("IMAGE_PROXY_ENABLED", true)
+	utils.ImageProxyMaxWidth = utils.GetIntEnv("IMAGE_PROXY_MAX_WIDTH", 1024)
+	utils.ImageCacheDir = utils.GetStringEnv("IMAGE_CACHE_DIR", "./image-cache")
+	utils.ImageCacheMaxMB = utils.GetIntEnv("IMAGE_CACHE_MAX_MB", 200)
+
+	err := httpclient.Init(httpclient.Config{
+		UserAgent:       utils.FetchUserAgent,
+		Timeout:         utils.FetchTimeout,
+		MaxResponseSize: int64(utils.FetchMaxResponseMB) << 20,
+		ProxyURL:        utils.FetchProxy,
+		CABundle:        utils.FetchCABundle,
+		HeadersFile:     utils.FetchHeadersFile,
+	})
+	if err != nil {
+		slog.Error("failed to configure outgoing http client", "error", err)
+
+		os.Exit(1)
+	}
 
 	slog.Info("Try to open database")
 
@@ -72,6 +105,10 @@ func main() {
 
 	go feeder.StartAutoRefresh()
 
+	go feeder.StartPruning()
+
+	feeder.StartJobWorkers()
+
 	go func() {
 		slog.Info("Starting RapidFeed MCP server", "listen", utils.MCPListen)
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
 )
@@ -14,8 +16,15 @@ var DB *sql.DB
 
 var ErrTokenNotFound = errors.New("token not found")
 
+var ErrItemNotFound = errors.New("item not found")
+
+var ErrJobNotFound = errors.New("job not found")
+
+// busyTimeoutMs - how long a connection waits for a lock held by a concurrent writer
+const busyTimeoutMs = 5000
+
 func InitDB(dbPath string) {
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", withBusyTimeout(dbPath))
 	if err != nil {
 		slog.Error("failed to initialize database connection", "error", err)
 
@@ -25,6 +34,21 @@ func InitDB(dbPath string) {
 	DB = db
 }
 
+// withBusyTimeout adds busy_timeout pragma to the dsn, so concurrent feed writers wait
+// for each other instead of failing with SQLITE_BUSY.
+func withBusyTimeout(dsn string) string {
+	if strings.Contains(dsn, "busy_timeout") {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)", dsn, separator, busyTimeoutMs)
+}
+
 func GetUserInfoById(userID int) (models.User, error) {
 	var user models.User
 
@@ -119,10 +143,56 @@ func DeleteUserToken(userID int) error {
 	return nil
 }
 
+// userFeedsQuery selects user subscriptions together with the health of their feed urls.
+const userFeedsQuery = `SELECT user_feeds.id, user_feeds.feed_url, user_feeds.title, COALESCE(user_feeds.category, ''),
+	user_feeds.full_article, user_feeds.interval_minutes,
+	COALESCE(feed_states.last_fetch_at, 0), COALESCE(feed_states.last_success_at, 0),
+	COALESCE(feed_states.last_status, 0), COALESCE(feed_states.last_error, ''),
+	COALESCE(feed_states.consecutive_failures, 0), COALESCE(feed_states.item_count, 0),
+	COALESCE(feed_states.retry_after_at, 0), COALESCE(feed_states.dead, 0),
+	COALESCE(feed_states.hint_interval_minutes, 0)
+	FROM user_feeds
+	LEFT JOIN feed_states ON feed_states.feed_url = user_feeds.feed_url
+	WHERE user_feeds.user_id = ?
+	ORDER BY user_feeds.id`
+
+func scanUserFeed(rows *sql.Rows) (models.UserFeed, error) {
+	var (
+		feed                                     models.UserFeed
+		interval                                 sql.NullInt64
+		lastFetchAt, lastSuccessAt, retryAfterAt int64
+	)
+
+	err := rows.Scan(&feed.ID, &feed.FeedURL, &feed.Title, &feed.Tags, &feed.FullArticle, &interval,
+		&lastFetchAt, &lastSuccessAt,
+		&feed.Health.LastStatus, &feed.Health.LastError,
+		&feed.Health.ConsecutiveFailures, &feed.Health.ItemCount,
+		&retryAfterAt, &feed.Health.Dead, &feed.Health.HintIntervalMinutes)
+	if err != nil {
+		return feed, err
+	}
+
+	feed.IntervalMinutes = nullIntPtr(interval)
+
+	feed.Health.LastFetchAt = unixOrZero(lastFetchAt)
+	feed.Health.LastSuccessAt = unixOrZero(lastSuccessAt)
+	feed.Health.RetryAfterAt = unixOrZero(retryAfterAt)
+
+	return feed, nil
+}
+
+func unixOrZero(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(ts, 0)
+}
+
 func GetUserFeeds(userID int) ([]models.UserFeed, error) {
 	var userFeeds []models.UserFeed
 
-	rows, err := DB.Query(`SELECT id, feed_url, title, COALESCE(category, '') FROM user_feeds WHERE user_id = ?`, userID)
+	rows, err := DB.Query(userFeedsQuery, userID)
 	if err != nil {
 		slog.Error("failed to get user feeds", "userID", userID)
 
@@ -135,9 +205,7 @@ func GetUserFeeds(userID int) ([]models.UserFeed, error) {
 	}()
 
 	for rows.Next() {
-		var feed models.UserFeed
-
-		err := rows.Scan(&feed.ID, &feed.FeedURL, &feed.Title, &feed.Tags)
+		feed, err := scanUserFeed(rows)
 		if err != nil {
 			slog.Error("failed to scan user feed rows", "userID", userID)
 
@@ -151,9 +219,20 @@ func GetUserFeeds(userID int) ([]models.UserFeed, error) {
 }
 
 func GetUserFeedUrls(userID int) ([]string, error) {
+	return getUserFeedUrls(`SELECT feed_url FROM user_feeds WHERE user_id = ?`, userID)
+}
+
+// GetUserLiveFeedUrls - returns user feed urls except the ones marked dead after repeated failures
+func GetUserLiveFeedUrls(userID int) ([]string, error) {
+	return getUserFeedUrls(`SELECT user_feeds.feed_url FROM user_feeds
+		LEFT JOIN feed_states ON feed_states.feed_url = user_feeds.feed_url
+		WHERE user_feeds.user_id = ? AND COALESCE(feed_states.dead, 0) = 0`, userID)
+}
+
+func getUserFeedUrls(query string, userID int) ([]string, error) {
 	var userFeeds []string
 
-	rows, err := DB.Query(`SELECT feed_url FROM user_feeds WHERE user_id = ?`, userID)
+	rows, err := DB.Query(query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select user feeds, error: %w", err)
 	}
@@ -222,7 +301,7 @@ func GetUsersWithFeeds() ([]models.UserWithFeeds, error) {
 	for _, user := range users {
 		var userFeeds []models.UserFeed
 
-		rows, err := DB.Query(`SELECT id, feed_url, title, COALESCE(category, '') FROM user_feeds WHERE user_id = ?`, user.ID)
+		rows, err := DB.Query(userFeedsQuery, user.ID)
 		if err != nil {
 			slog.Error("failed to get user feeds", "user", user)
 
@@ -230,9 +309,7 @@ func GetUsersWithFeeds() ([]models.UserWithFeeds, error) {
 		}
 
 		for rows.Next() {
-			var feed models.UserFeed
-
-			err := rows.Scan(&feed.ID, &feed.FeedURL, &feed.Title, &feed.Tags)
+			feed, err := scanUserFeed(rows)
 			if err != nil {
 				slog.Error("failed to scan user feed rows", "user", user)
 
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// ItemSaveResult - what SaveFeedItem did with an incoming item
+type ItemSaveResult int
+
+const (
+	ItemUnchanged ItemSaveResult = iota
+	ItemInserted
+	ItemUpdated
+)
+
+// SaveFeedItem inserts the item or updates title, description and content of the stored one with the same GUID.
+// Items saved before GUIDs were tracked, or identified by the former hash, are matched by link once
+// and get the GUID assigned.
+func SaveFeedItem(item models.IncomingFeedItem) (ItemSaveResult, error) {
+	var stored storedFeedItem
+
+	err := DB.QueryRow(`SELECT id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(content, '')
+		FROM feeds WHERE feed_url = ? AND guid = ?`,
+		item.FeedURL, item.GUID).Scan(&stored.id, &stored.title, &stored.description, &stored.content)
+	if errors.Is(err, sql.ErrNoRows) {
+		stored, err = adoptLegacyFeedItem(item)
+	}
+
+	if err != nil {
+		return ItemUnchanged, fmt.Errorf("failed to find stored item %s of feed %s: %w", item.GUID, item.FeedURL, err)
+	}
+
+	if stored.id == 0 {
+		return insertFeedItem(item)
+	}
+
+	if stored.title == item.Title && stored.description == item.Description && stored.content == item.Content {
+		return ItemUnchanged, nil
+	}
+
+	_, err = DB.Exec(`UPDATE feeds SET title = ?, description = ?, content = ?, link = ? WHERE id = ?`,
+		item.Title, item.Description, item.Content, item.Link, stored.id)
+	if err != nil {
+		return ItemUnchanged, fmt.Errorf("failed to update item %d: %w", stored.id, err)
+	}
+
+	return ItemUpdated, nil
+}
+
+// insertFeedItem stores a new item, it is left unchanged if a concurrent fetch of the same feed stored it first.
+func insertFeedItem(item models.IncomingFeedItem) (ItemSaveResult, error) {
+	res, err := DB.Exec(`INSERT INTO feeds (title, link, date, source, description, content, feed_url, guid, first_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_url, guid) DO NOTHING`,
+		item.Title, item.Link, item.Date, item.Source, item.Description, item.Content, item.FeedURL, item.GUID,
+		item.FirstSeenAt.Unix())
+	if err != nil {
+		return ItemUnchanged, fmt.Errorf("failed to insert item %s of feed %s: %w", item.GUID, item.FeedURL, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return ItemUnchanged, fmt.Errorf("failed to check insert of item %s of feed %s: %w", item.GUID, item.FeedURL, err)
+	}
+
+	if inserted == 0 {
+		return ItemUnchanged, nil
+	}
+
+	return ItemInserted, nil
+}
+
+// storedFeedItem - fields of a stored item compared with the incoming one
+type storedFeedItem struct {
+	id                          int
+	title, description, content string
+}
+
+// adoptLegacyFeedItem assigns the GUID to an item stored without one, or with the former sha256 identity
+// of GUID-less items, and having the same link. Returns zero id if there is no such item.
+func adoptLegacyFeedItem(item models.IncomingFeedItem) (storedFeedItem, error) {
+	var stored storedFeedItem
+
+	if item.Link == "" {
+		return stored, nil
+	}
+
+	err := DB.QueryRow(`SELECT id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(content, '') FROM feeds
+		WHERE feed_url = ? AND (guid IS NULL OR guid LIKE 'sha256:%') AND link = ? ORDER BY id LIMIT 1`,
+		item.FeedURL, item.Link).Scan(&stored.id, &stored.title, &stored.description, &stored.content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storedFeedItem{}, nil
+		}
+
+		return storedFeedItem{}, err
+	}
+
+	if _, err := DB.Exec(`UPDATE feeds SET guid = ? WHERE id = ?`, item.GUID, stored.id); err != nil {
+		return storedFeedItem{}, err
+	}
+
+	return stored, nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func TestSaveFeedItem(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	const feedURL = "https://a.example.com/rss"
+
+	countItems := func(t *testing.T) int {
+		t.Helper()
+
+		var n int
+		if err := DB.QueryRow(`SELECT COUNT(*) FROM feeds WHERE feed_url = ?`, feedURL).Scan(&n); err != nil {
+			t.Fatalf("failed to count items: %v", err)
+		}
+
+		return n
+	}
+
+	save := func(t *testing.T, item models.IncomingFeedItem, want ItemSaveResult) {
+		t.Helper()
+
+		item.FeedURL = feedURL
+
+		got, err := SaveFeedItem(item)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != want {
+			t.Fatalf("expected save result %d for %+v, got %d", want, item, got)
+		}
+	}
+
+	legacy := insertTestItem(t, feedURL, "https://a.example.com/legacy", time.Now())
+
+	t.Run("legacy item is adopted by link", func(t *testing.T) {
+		save(t, models.IncomingFeedItem{GUID: "legacy", Link: "https://a.example.com/legacy",
+			Title: "title https://a.example.com/legacy", Description: "description"}, ItemUnchanged)
+
+		var guid string
+		if err := DB.QueryRow(`SELECT guid FROM feeds WHERE id = ?`, legacy).Scan(&guid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if guid != "legacy" || countItems(t) != 1 {
+			t.Fatalf("expected legacy item to get guid, got %q and %d items", guid, countItems(t))
+		}
+	})
+
+	t.Run("item with former hash identity is adopted by link", func(t *testing.T) {
+		hashed := insertTestItem(t, feedURL, "https://a.example.com/hashed", time.Now())
+		if _, err := DB.Exec(`UPDATE feeds SET guid = 'sha256:abc' WHERE id = ?`, hashed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		save(t, models.IncomingFeedItem{GUID: "link:https://a.example.com/hashed", Link: "https://a.example.com/hashed",
+			Title: "title https://a.example.com/hashed", Description: "description"}, ItemUnchanged)
+
+		if _, err := DB.Exec(`DELETE FROM feeds WHERE id = ?`, hashed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n := countItems(t); n != 1 {
+			t.Fatalf("expected hashed item to be adopted, got %d items", n)
+		}
+	})
+
+	t.Run("items sharing a link are kept apart", func(t *testing.T) {
+		save(t, models.IncomingFeedItem{GUID: "1", Link: "https://a.example.com/", Title: "first"}, ItemInserted)
+		save(t, models.IncomingFeedItem{GUID: "2", Link: "https://a.example.com/", Title: "second"}, ItemInserted)
+
+		if n := countItems(t); n != 3 {
+			t.Fatalf("expected 3 items, got %d", n)
+		}
+	})
+
+	t.Run("same item saved twice is stored once", func(t *testing.T) {
+		save(t, models.IncomingFeedItem{GUID: "twice", Link: "https://a.example.com/twice", Title: "twice"}, ItemInserted)
+		save(t, models.IncomingFeedItem{GUID: "twice", Link: "https://a.example.com/twice", Title: "twice"}, ItemUnchanged)
+
+		if n := countItems(t); n != 4 {
+			t.Fatalf("expected 4 items, got %d", n)
+		}
+	})
+
+	t.Run("item inserted by a concurrent save is unchanged", func(t *testing.T) {
+		item := models.IncomingFeedItem{FeedURL: feedURL, GUID: "racy", Link: "https://a.example.com/racy", Title: "racy"}
+
+		for _, want := range []ItemSaveResult{ItemInserted, ItemUnchanged} {
+			got, err := insertFeedItem(item)
+			if err != nil || got != want {
+				t.Fatalf("expected save result %d, got %d %v", want, got, err)
+			}
+		}
+
+		if n := countItems(t); n != 5 {
+			t.Fatalf("expected 5 items, got %d", n)
+		}
+	})
+
+	t.Run("changed item is updated in place", func(t *testing.T) {
+		save(t, models.IncomingFeedItem{GUID: "1", Link: "https://a.example.com/?utm=x", Title: "first"}, ItemUnchanged)
+		save(t, models.IncomingFeedItem{GUID: "1", Link: "https://a.example.com/?utm=x", Title: "first, corrected"}, ItemUpdated)
+		save(t, models.IncomingFeedItem{GUID: "1", Link: "https://a.example.com/?utm=x", Title: "first, corrected"}, ItemUnchanged)
+
+		var title string
+		if err := DB.QueryRow(`SELECT title FROM feeds WHERE feed_url = ? AND guid = '1'`, feedURL).Scan(&title); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if title != "first, corrected" || countItems(t) != 5 {
+			t.Fatalf("expected updated title and 5 items, got %q and %d", title, countItems(t))
+		}
+	})
+
+	t.Run("changed content is updated", func(t *testing.T) {
+		save(t, models.IncomingFeedItem{GUID: "1", Link: "https://a.example.com/?utm=x", Title: "first, corrected",
+			Content: "<p>full text</p>"}, ItemUpdated)
+
+		var content string
+		if err := DB.QueryRow(`SELECT content FROM feeds WHERE feed_url = ? AND guid = '1'`, feedURL).Scan(&content); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if content != "<p>full text</p>" {
+			t.Fatalf("expected updated content, got %q", content)
+		}
+	})
+}
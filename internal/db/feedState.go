@@ -0,0 +1,164 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// GetFeedValidators returns ETag and Last-Modified values saved from the last successful fetch of the feed.
+func GetFeedValidators(feedURL string) (string, string, error) {
+	var etag, lastModified string
+
+	err := DB.QueryRow(`SELECT etag, last_modified FROM feed_states WHERE feed_url = ?`, feedURL).Scan(&etag, &lastModified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", nil
+		}
+
+		return "", "", fmt.Errorf("failed to get validators for feed %s: %w", feedURL, err)
+	}
+
+	return etag, lastModified, nil
+}
+
+// SetFeedValidators stores ETag and Last-Modified values to be sent with the next fetch of the feed.
+func SetFeedValidators(feedURL, etag, lastModified string) error {
+	_, err := DB.Exec(`INSERT INTO feed_states (feed_url, etag, last_modified, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(feed_url) DO UPDATE SET
+			etag = excluded.etag,
+			last_modified = excluded.last_modified,
+			updated_at = excluded.updated_at`, feedURL, etag, lastModified)
+	if err != nil {
+		return fmt.Errorf("failed to set validators for feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+// SetFeedHints stores refresh hints the publisher sent with the feed.
+func SetFeedHints(feedURL string, hints models.FeedHints) error {
+	days := make([]int, 0, len(hints.SkipDays))
+	for _, day := range hints.SkipDays {
+		days = append(days, int(day))
+	}
+
+	_, err := DB.Exec(`INSERT INTO feed_states (feed_url, hint_interval_minutes, skip_hours, skip_days, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(feed_url) DO UPDATE SET
+			hint_interval_minutes = excluded.hint_interval_minutes,
+			skip_hours = excluded.skip_hours,
+			skip_days = excluded.skip_days,
+			updated_at = excluded.updated_at`,
+		feedURL, hints.MinIntervalMinutes, joinInts(hints.SkipHours), joinInts(days))
+	if err != nil {
+		return fmt.Errorf("failed to set refresh hints for feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, strconv.Itoa(v))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// splitInts parses values saved by joinInts, malformed ones are skipped
+func splitInts(raw string) []int {
+	var values []int
+
+	for _, part := range strings.Split(raw, ",") {
+		if v, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// maxFeedErrorLength - longer fetch errors are truncated before saving
+const maxFeedErrorLength = 500
+
+// SetFeedFetchSucceeded records a successful fetch attempt of the feed and resets its failures counter and backoff.
+// Negative itemCount keeps the previous value, e.g. when the publisher answered 304.
+func SetFeedFetchSucceeded(feedURL string, fetchedAt time.Time, status, itemCount int) error {
+	_, err := DB.Exec(`INSERT INTO feed_states
+		(feed_url, last_fetch_at, last_success_at, last_status, last_error, consecutive_failures, item_count, updated_at)
+		VALUES (?, ?, ?, ?, '', 0, MAX(?, 0), CURRENT_TIMESTAMP)
+		ON CONFLICT(feed_url) DO UPDATE SET
+			last_fetch_at = excluded.last_fetch_at,
+			last_success_at = excluded.last_success_at,
+			last_status = excluded.last_status,
+			last_error = '',
+			consecutive_failures = 0,
+			retry_after_at = NULL,
+			dead = 0,
+			item_count = CASE WHEN ? < 0 THEN feed_states.item_count ELSE excluded.item_count END,
+			updated_at = excluded.updated_at`,
+		feedURL, fetchedAt.Unix(), fetchedAt.Unix(), status, itemCount, itemCount)
+	if err != nil {
+		return fmt.Errorf("failed to save fetch result for feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+// SetFeedFetchFailed records a failed fetch attempt of the feed and increments its failures counter.
+// Non-zero retryAfter postpones the next attempt as the publisher asked, the feed is marked dead
+// once failures in a row reach deadAfter, zero deadAfter never marks it.
+func SetFeedFetchFailed(feedURL string, fetchedAt time.Time, status int, fetchErr string, retryAfter time.Time, deadAfter int) error {
+	if runes := []rune(fetchErr); len(runes) > maxFeedErrorLength {
+		fetchErr = string(runes[:maxFeedErrorLength])
+	}
+
+	var retryAfterAt sql.NullInt64
+	if !retryAfter.IsZero() {
+		retryAfterAt = sql.NullInt64{Int64: retryAfter.Unix(), Valid: true}
+	}
+
+	_, err := DB.Exec(`INSERT INTO feed_states
+		(feed_url, last_fetch_at, last_status, last_error, consecutive_failures, retry_after_at, dead, updated_at)
+		VALUES (?, ?, ?, ?, 1, ?, ? = 1, CURRENT_TIMESTAMP)
+		ON CONFLICT(feed_url) DO UPDATE SET
+			last_fetch_at = excluded.last_fetch_at,
+			last_status = excluded.last_status,
+			last_error = excluded.last_error,
+			consecutive_failures = feed_states.consecutive_failures + 1,
+			retry_after_at = excluded.retry_after_at,
+			dead = CASE WHEN ? > 0 AND feed_states.consecutive_failures + 1 >= ? THEN 1 ELSE feed_states.dead END,
+			updated_at = excluded.updated_at`,
+		feedURL, fetchedAt.Unix(), status, fetchErr, retryAfterAt, deadAfter, deadAfter, deadAfter)
+	if err != nil {
+		return fmt.Errorf("failed to save fetch error for feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+// ReviveUserFeed clears the dead mark, failures counter, backoff and last fetch time of the user subscription
+// feed url, so autorefresh fetches it on its next check. Returns the feed url.
+func ReviveUserFeed(userID int, feedID string) (string, error) {
+	var feedURL string
+
+	err := DB.QueryRow(`SELECT feed_url FROM user_feeds WHERE id = ? AND user_id = ?`, feedID, userID).Scan(&feedURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to get feed %s of user %d: %w", feedID, userID, err)
+	}
+
+	_, err = DB.Exec(`UPDATE feed_states SET dead = 0, consecutive_failures = 0, retry_after_at = NULL,
+		last_fetch_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE feed_url = ?`, feedURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to revive feed %s: %w", feedURL, err)
+	}
+
+	return feedURL, nil
+}
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -49,19 +51,16 @@ func UpdateUserFeed(userId int, feedId, feedTitle, feedTags string) error {
 	return nil
 }
 
-func GetTotalUserFeedItemsCount(userFeeds []string) (int, error) {
+func GetTotalUserFeedItemsCount(userID int, filter models.FeedItemsFilter) (int, error) {
 	var totalCount int
 
-	placeholders := strings.Repeat(",?", len(userFeeds))[1:]
+	where, args := userFeedItemsWhere(filter)
 
-	query := fmt.Sprintf("SELECT COUNT(*) FROM feeds WHERE feed_url IN (%s)", placeholders)
-	args := make([]interface{}, len(userFeeds))
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM feeds %s
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE %s`, searchJoin(filter), where)
 
-	for i, u := range userFeeds {
-		args[i] = u
-	}
-
-	rows, err := DB.Query(query, args...)
+	rows, err := DB.Query(query, append([]any{userID}, args...)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query user feed items count: %w", err)
 	}
@@ -81,26 +80,34 @@ func GetTotalUserFeedItemsCount(userFeeds []string) (int, error) {
 	return totalCount, nil
 }
 
-func GetUserFeedItems(userID int, userFeeds []string, perPage, offset int) ([]models.FeedItem, error) {
+func GetUserFeedItems(userID int, filter models.FeedItemsFilter, perPage, offset int) ([]models.FeedItem, error) {
 	var items []models.FeedItem
 
-	placeholders := strings.Repeat(",?", len(userFeeds))[1:]
+	where, whereArgs := userFeedItemsWhere(filter)
 
-	argsWithPagination := make([]any, 0, len(userFeeds)+3) // +3 for userID, perpage and offset
-	argsWithPagination = append(argsWithPagination, userID)
-	for _, u := range userFeeds {
-		argsWithPagination = append(argsWithPagination, u)
+	args := make([]any, 0, len(whereArgs)+4) // +4 for two userIDs, perpage and offset
+	args = append(args, userID, userID)
+	args = append(args, whereArgs...)
+	args = append(args, perPage, offset)
+
+	highlights := `'', ''`
+	order := timelineOrder(filter.SortBy)
+
+	if ftsQuery(filter.Query) != "" {
+		highlights = fmt.Sprintf(`highlight(feeds_fts, 0, '%[1]s', '%[2]s'), snippet(feeds_fts, 1, '%[1]s', '%[2]s', '…', %[3]d)`,
+			models.HighlightStart, models.HighlightEnd, searchSnippetTokens)
+		order = "feeds_fts.rank, " + order
 	}
 
-	argsWithPagination = append(argsWithPagination, perPage, offset)
-	query := fmt.Sprintf(`SELECT feeds.title, feeds.link, feeds.date,
+	query := fmt.Sprintf(`SELECT feeds.id, feeds.title, feeds.link, feeds.date,
 		COALESCE(NULLIF(user_feeds.title, ''), feeds.source) AS source,
-		feeds.description
-		FROM feeds
-		JOIN user_feeds ON user_feeds.feed_url = feeds.feed_url AND user_feeds.user_id = ?
-		WHERE feeds.feed_url IN (%s) ORDER BY date DESC LIMIT ? OFFSET ?`, placeholders)
+		feeds.description, COALESCE(user_item_states.is_read, 0), COALESCE(user_item_states.starred, 0), %s
+		FROM feeds %s
+		LEFT JOIN user_feeds ON user_feeds.feed_url = feeds.feed_url AND user_feeds.user_id = ?
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE %s ORDER BY %s LIMIT ? OFFSET ?`, highlights, searchJoin(filter), where, order)
 
-	rows, err := DB.Query(query, argsWithPagination...)
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user feed items: %w", err)
 	}
@@ -114,7 +121,8 @@ func GetUserFeedItems(userID int, userFeeds []string, perPage, offset int) ([]mo
 	for rows.Next() {
 		var item models.FeedItem
 
-		err := rows.Scan(&item.Title, &item.Link, &item.Date, &item.Source, &item.Description)
+		err := rows.Scan(&item.ID, &item.Title, &item.Link, &item.Date, &item.Source, &item.Description, &item.IsRead, &item.IsStarred,
+			&item.TitleHighlight, &item.Snippet)
 		if err != nil {
 			slog.Error("failed to scan feed urls", "error", err)
 
@@ -129,6 +137,179 @@ func GetUserFeedItems(userID int, userFeeds []string, perPage, offset int) ([]mo
 	return items, nil
 }
 
+// GetUserFeedItemsAfter returns up to limit items matching the filter, newest first, starting after the cursor,
+// nil cursor starts from the newest item. Dates are returned as stored. The returned cursor points at the last
+// item and is nil when there are no more items. Keyset pagination keeps pages stable while new items arrive.
+func GetUserFeedItemsAfter(userID int, filter models.FeedItemsFilter, cursor *models.FeedItemsCursor, limit int) ([]models.FeedItem, *models.FeedItemsCursor, error) {
+	where, whereArgs := userFeedItemsWhere(filter)
+
+	args := make([]any, 0, len(whereArgs)+6)
+	args = append(args, userID, userID)
+	args = append(args, whereArgs...)
+
+	if cursor != nil {
+		where += " AND (datetime(feeds.date) < datetime(?) OR (datetime(feeds.date) = datetime(?) AND feeds.id < ?))"
+		args = append(args, cursor.Date, cursor.Date, cursor.ID)
+	}
+
+	// one extra item tells whether there is a next page
+	args = append(args, limit+1)
+
+	query := fmt.Sprintf(`SELECT feeds.id, feeds.title, feeds.link, feeds.date,
+		COALESCE(NULLIF(user_feeds.title, ''), feeds.source) AS source,
+		feeds.description, COALESCE(user_item_states.is_read, 0), COALESCE(user_item_states.starred, 0)
+		FROM feeds %s
+		LEFT JOIN user_feeds ON user_feeds.feed_url = feeds.feed_url AND user_feeds.user_id = ?
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE %s AND datetime(feeds.date) IS NOT NULL
+		ORDER BY datetime(feeds.date) DESC, feeds.id DESC LIMIT ?`, searchJoin(filter), where)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user feed items page: %w", err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close user feed items rows", "error", closeErr)
+		}
+	}()
+
+	items := make([]models.FeedItem, 0, limit)
+
+	for rows.Next() {
+		var item models.FeedItem
+
+		err := rows.Scan(&item.ID, &item.Title, &item.Link, &item.Date, &item.Source, &item.Description, &item.IsRead, &item.IsStarred)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan user feed item: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get user feed items page: %w", err)
+	}
+
+	if len(items) <= limit {
+		return items, nil, nil
+	}
+
+	items = items[:limit]
+	last := items[limit-1]
+
+	return items, &models.FeedItemsCursor{Date: last.Date, ID: last.ID}, nil
+}
+
+// GetUserFeedItem returns a single item with its raw content, extracted full article is preferred
+// over the content shipped in the feed. The item must come from the user
+// subscriptions or be starred by the user, otherwise ErrItemNotFound is returned.
+func GetUserFeedItem(userID, itemID int) (models.FeedItem, error) {
+	var item models.FeedItem
+
+	err := DB.QueryRow(`SELECT feeds.id, feeds.title, feeds.link, feeds.date,
+		COALESCE(NULLIF(user_feeds.title, ''), feeds.source) AS source,
+		COALESCE(feeds.description, ''), COALESCE(NULLIF(feeds.full_content, ''), feeds.content, ''),
+		COALESCE(user_item_states.is_read, 0), COALESCE(user_item_states.starred, 0)
+		FROM feeds
+		LEFT JOIN user_feeds ON user_feeds.feed_url = feeds.feed_url AND user_feeds.user_id = ?
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE feeds.id = ? AND (user_feeds.id IS NOT NULL OR COALESCE(user_item_states.starred, 0) = 1)`,
+		userID, userID, itemID).Scan(&item.ID, &item.Title, &item.Link, &item.Date, &item.Source,
+		&item.Description, &item.Content, &item.IsRead, &item.IsStarred)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return item, ErrItemNotFound
+		}
+
+		return item, fmt.Errorf("failed to get item id %d for user id %d: %w", itemID, userID, err)
+	}
+
+	item.Date = timeToHumanReadable(item.Date)
+
+	return item, nil
+}
+
+// userFeedItemsWhere builds the WHERE clause shared by user feed items queries.
+// It expects user_item_states to be joined for the same user and searchJoin of the filter.
+func userFeedItemsWhere(filter models.FeedItemsFilter) (string, []any) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, len(filter.FeedURLs))
+
+	switch {
+	case len(filter.FeedURLs) > 0:
+		placeholders := strings.Repeat(",?", len(filter.FeedURLs))[1:]
+		conditions = append(conditions, fmt.Sprintf("feeds.feed_url IN (%s)", placeholders))
+
+		for _, u := range filter.FeedURLs {
+			args = append(args, u)
+		}
+	case !filter.StarredOnly:
+		// never fall back to the whole feeds table
+		conditions = append(conditions, "1 = 0")
+	}
+
+	if filter.StarredOnly {
+		conditions = append(conditions, "COALESCE(user_item_states.starred, 0) = 1")
+	}
+
+	if filter.HideRead {
+		conditions = append(conditions, "COALESCE(user_item_states.is_read, 0) = 0")
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "datetime(feeds.date) >= datetime(?)")
+		args = append(args, filter.From.Format(time.RFC3339))
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "datetime(feeds.date) < datetime(?)")
+		args = append(args, filter.To.Format(time.RFC3339))
+	}
+
+	if match := ftsQuery(filter.Query); match != "" {
+		conditions = append(conditions, "feeds_fts MATCH ?")
+		args = append(args, match)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// timelineOrder returns ORDER BY expression for the timeline sort, publish date is the default.
+func timelineOrder(sortBy string) string {
+	if sortBy == models.SortByArrival {
+		return "feeds.first_seen_at DESC, feeds.id DESC"
+	}
+
+	return "datetime(feeds.date) DESC, feeds.id DESC"
+}
+
+// searchSnippetTokens - max number of tokens in a search result snippet
+const searchSnippetTokens = 24
+
+// searchJoin returns the join of the full-text index needed when the filter has a search query.
+func searchJoin(filter models.FeedItemsFilter) string {
+	if ftsQuery(filter.Query) == "" {
+		return ""
+	}
+
+	return "JOIN feeds_fts ON feeds_fts.rowid = feeds.id"
+}
+
+// ftsQuery turns user input into FTS5 query matching all of its words,
+// every word is quoted so operators and special characters are searched literally.
+func ftsQuery(raw string) string {
+	words := strings.Fields(raw)
+	terms := make([]string, 0, len(words))
+
+	for _, word := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`)
+	}
+
+	return strings.Join(terms, " ")
+}
+
 func timeToHumanReadable(t string) string {
 	parsedTime, err := time.Parse(time.RFC3339, t)
 	if err != nil {
@@ -0,0 +1,358 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func TestSearchUserFeedItems(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("alice", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const (
+		feedA = "https://a.example.com/rss"
+		feedB = "https://b.example.com/rss"
+	)
+
+	if err := AddUserFeed(user.ID, "A", feedA, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	insert := func(feedURL, title, description string) int {
+		t.Helper()
+
+		res, err := DB.Exec(`INSERT INTO feeds (title, link, date, source, description, feed_url) VALUES (?, ?, ?, ?, ?, ?)`,
+			title, feedURL+"/"+title, time.Now().Format(time.RFC3339), "source", description, feedURL)
+		if err != nil {
+			t.Fatalf("failed to insert item: %v", err)
+		}
+
+		id, _ := res.LastInsertId()
+
+		return int(id)
+	}
+
+	match := insert(feedA, "Go 1.25 released", "The new release brings faster builds")
+	insert(feedA, "Rust news", "Nothing about gophers here")
+	insert(feedB, "Go tips", "Foreign feed mentions go release too")
+
+	search := func(query string) []models.FeedItem {
+		t.Helper()
+
+		filter := models.FeedItemsFilter{FeedURLs: []string{feedA}, Query: query}
+
+		items, err := GetUserFeedItems(user.ID, filter, 10, 0)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", query, err)
+		}
+
+		total, err := GetTotalUserFeedItemsCount(user.ID, filter)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", query, err)
+		}
+
+		if total != len(items) {
+			t.Fatalf("count %d doesn't match items %d for %q", total, len(items), query)
+		}
+
+		return items
+	}
+
+	t.Run("matches all words within subscriptions", func(t *testing.T) {
+		items := search("go RELEASE")
+		if len(items) != 1 || items[0].ID != match {
+			t.Fatalf("expected only item %d, got %+v", match, items)
+		}
+
+		wantTitle := models.HighlightStart + "Go" + models.HighlightEnd + " 1.25 released"
+		if items[0].TitleHighlight != wantTitle {
+			t.Fatalf("expected highlighted title %q, got %q", wantTitle, items[0].TitleHighlight)
+		}
+
+		if !strings.Contains(items[0].Snippet, models.HighlightStart+"release"+models.HighlightEnd) {
+			t.Fatalf("expected highlighted snippet, got %q", items[0].Snippet)
+		}
+	})
+
+	t.Run("special characters are searched literally", func(t *testing.T) {
+		if items := search(`"go" OR NEAR( -*`); len(items) != 0 {
+			t.Fatalf("expected no items, got %+v", items)
+		}
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		old := insert(feedA, "Go 1.24 released", "The previous release")
+		if _, err := DB.Exec(`UPDATE feeds SET date = ? WHERE id = ?`,
+			time.Now().AddDate(0, -2, 0).Format(time.RFC3339), old); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		t.Cleanup(func() {
+			if _, err := DB.Exec(`DELETE FROM feeds WHERE id = ?`, old); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+
+		monthAgo := time.Now().AddDate(0, -1, 0)
+
+		for _, tc := range []struct {
+			name     string
+			from, to time.Time
+			want     []int
+		}{
+			{name: "no range", want: []int{match, old}},
+			{name: "from", from: monthAgo, want: []int{match}},
+			{name: "to", to: monthAgo, want: []int{old}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				filter := models.FeedItemsFilter{FeedURLs: []string{feedA}, Query: "released", From: tc.from, To: tc.to}
+
+				items, err := GetUserFeedItems(user.ID, filter, 10, 0)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				got := make([]int, 0, len(items))
+				for _, item := range items {
+					got = append(got, item.ID)
+				}
+
+				slices.Sort(got)
+
+				if !slices.Equal(got, tc.want) {
+					t.Fatalf("expected items %v, got %v", tc.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("index follows updates and deletes", func(t *testing.T) {
+		if _, err := DB.Exec(`UPDATE feeds SET title = 'Renamed' WHERE id = ?`, match); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if items := search("renamed"); len(items) != 1 {
+			t.Fatalf("expected updated item to be found, got %+v", items)
+		}
+
+		if _, err := DB.Exec(`DELETE FROM feeds WHERE id = ?`, match); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if items := search("renamed"); len(items) != 0 {
+			t.Fatalf("expected deleted item to be gone, got %+v", items)
+		}
+	})
+}
+
+func TestUserFeedItemsTimelineSort(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("bob", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("bob")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := AddUserFeed(user.ID, "A", feedURL, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	now := time.Now()
+
+	// the older post arrived later, e.g. a backdated entry, its date keeps a publisher offset
+	// which makes it greater than the newer one as a string
+	for _, item := range []models.IncomingFeedItem{
+		{GUID: "new", Date: now.UTC().Format(time.RFC3339), FirstSeenAt: now.Add(-time.Hour)},
+		{GUID: "old", Date: now.Add(-3 * time.Hour).In(time.FixedZone("", 14*60*60)).Format(time.RFC3339), FirstSeenAt: now},
+	} {
+		item.FeedURL = feedURL
+		item.Title = item.GUID
+
+		if _, err := SaveFeedItem(item); err != nil {
+			t.Fatalf("failed to save item: %v", err)
+		}
+	}
+
+	sortBy, err := GetUserTimelineSort(user.ID)
+	if err != nil || sortBy != models.SortByPublished {
+		t.Fatalf("expected publish date sort by default, got %q %v", sortBy, err)
+	}
+
+	firstTitle := func() string {
+		t.Helper()
+
+		sortBy, err := GetUserTimelineSort(user.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		items, err := GetUserFeedItems(user.ID, models.FeedItemsFilter{FeedURLs: []string{feedURL}, SortBy: sortBy}, 10, 0)
+		if err != nil || len(items) != 2 {
+			t.Fatalf("unexpected items: %+v %v", items, err)
+		}
+
+		return items[0].Title
+	}
+
+	if title := firstTitle(); title != "new" {
+		t.Fatalf("expected newest published item first, got %q", title)
+	}
+
+	if err := SetUserTimelineSort(user.ID, models.SortByArrival); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if title := firstTitle(); title != "old" {
+		t.Fatalf("expected latest arrived item first, got %q", title)
+	}
+
+	if err := SetUserTimelineSort(user.ID, "random"); err == nil {
+		t.Fatalf("expected error for unknown sort")
+	}
+}
+
+func TestGetUserFeedItem(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	for _, username := range []string{"alice", "bob"} {
+		if err := RegisterUser(username, "secret"); err != nil {
+			t.Fatalf("failed to register user: %v", err)
+		}
+	}
+
+	alice, err := GetUserInfoByUsername("alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	bob, err := GetUserInfoByUsername("bob")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := AddUserFeed(alice.ID, "A", feedURL, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	itemID := insertTestItem(t, feedURL, "https://a.example.com/1", time.Now())
+
+	if _, err := DB.Exec(`UPDATE feeds SET content = '<p>full</p>' WHERE id = ?`, itemID); err != nil {
+		t.Fatalf("failed to set content: %v", err)
+	}
+
+	item, err := GetUserFeedItem(alice.ID, itemID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if item.ID != itemID || item.Content != "<p>full</p>" || item.Source != "A" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+
+	if _, err := GetUserFeedItem(bob.ID, itemID); !errors.Is(err, ErrItemNotFound) {
+		t.Fatalf("expected item of foreign feed to be not found, got %v", err)
+	}
+
+	if err := SetItemStarred(alice.ID, itemID, true); err != nil {
+		t.Fatalf("failed to star item: %v", err)
+	}
+
+	feeds, err := GetUserFeeds(alice.ID)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("failed to get feeds: %v", err)
+	}
+
+	if err := RemoveUserFeed(alice.ID, strconv.Itoa(feeds[0].ID)); err != nil {
+		t.Fatalf("failed to remove feed: %v", err)
+	}
+
+	if _, err := GetUserFeedItem(alice.ID, itemID); err != nil {
+		t.Fatalf("expected starred item to stay available after unsubscribing, got %v", err)
+	}
+}
+
+func TestGetUserFeedItemsAfter(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("alice", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := AddUserFeed(user.ID, "A", feedURL, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+
+	// two items share the date, the id breaks the tie
+	oldest := insertTestItem(t, feedURL, "https://a.example.com/1", now.Add(-3*time.Hour))
+	tieLow := insertTestItem(t, feedURL, "https://a.example.com/2", now.Add(-2*time.Hour))
+	tieHigh := insertTestItem(t, feedURL, "https://a.example.com/3", now.Add(-2*time.Hour))
+	newest := insertTestItem(t, feedURL, "https://a.example.com/4", now.Add(-time.Hour))
+	insertTestItem(t, feedURL, "https://a.example.com/5", now.AddDate(0, 0, -2))
+
+	filter := models.FeedItemsFilter{FeedURLs: []string{feedURL}, From: now.AddDate(0, 0, -1), To: now}
+
+	var (
+		got    []int
+		cursor *models.FeedItemsCursor
+		pages  int
+	)
+
+	for {
+		items, next, err := GetUserFeedItemsAfter(user.ID, filter, cursor, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, item := range items {
+			got = append(got, item.ID)
+		}
+
+		pages++
+
+		if next == nil {
+			break
+		}
+
+		if pages > 3 {
+			t.Fatalf("pagination doesn't end, got %v", got)
+		}
+
+		cursor = next
+	}
+
+	want := []int{newest, tieHigh, tieLow, oldest}
+	if !slices.Equal(got, want) || pages != 2 {
+		t.Fatalf("expected items %v in 2 pages, got %v in %d pages", want, got, pages)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// SetUserFeedFullArticle - turns full article extraction of the user subscription on or off
+func SetUserFeedFullArticle(userID int, feedID string, enabled bool) error {
+	_, err := DB.Exec(`UPDATE user_feeds SET full_article = ? WHERE id = ? AND user_id = ?`, enabled, feedID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set full article mode of feed id %s for user id %d: %w", feedID, userID, err)
+	}
+
+	return nil
+}
+
+// FeedWantsFullArticle reports whether any subscriber of the feed url asked for full articles.
+func FeedWantsFullArticle(feedURL string) (bool, error) {
+	var wanted bool
+
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM user_feeds WHERE feed_url = ? AND full_article = 1)`, feedURL).
+		Scan(&wanted)
+	if err != nil {
+		return false, fmt.Errorf("failed to check full article mode of feed %s: %w", feedURL, err)
+	}
+
+	return wanted, nil
+}
+
+// GetItemsWithoutFullContent returns up to limit newest items of the feed which article
+// was never downloaded, only ID and Link of the items are filled.
+func GetItemsWithoutFullContent(feedURL string, limit int) ([]models.FeedItem, error) {
+	var items []models.FeedItem
+
+	rows, err := DB.Query(`SELECT id, link FROM feeds
+		WHERE feed_url = ? AND full_content_fetched_at IS NULL AND COALESCE(link, '') != ''
+		ORDER BY COALESCE(first_seen_at, 0) DESC, id DESC LIMIT ?`, feedURL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items without full content of feed %s: %w", feedURL, err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close items without full content rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var item models.FeedItem
+		if err := rows.Scan(&item.ID, &item.Link); err != nil {
+			return nil, fmt.Errorf("failed to scan item without full content: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// SetItemFullContent saves the article extracted from the item page, empty content records a failed attempt.
+func SetItemFullContent(itemID int, content string, fetchedAt time.Time) error {
+	_, err := DB.Exec(`UPDATE feeds SET full_content = ?, full_content_fetched_at = ? WHERE id = ?`,
+		content, fetchedAt.Unix(), itemID)
+	if err != nil {
+		return fmt.Errorf("failed to save full content of item %d: %w", itemID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SetItemsReadState marks the given feed items as read or unread for the user.
+// Items outside the user's subscriptions are silently ignored.
+func SetItemsReadState(userID int, itemIDs []int, read bool) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	placeholders := strings.Repeat(",?", len(itemIDs))[1:]
+
+	query := fmt.Sprintf(`INSERT INTO user_item_states (user_id, item_id, is_read, read_at, updated_at)
+		SELECT ?, feeds.id, ?, CASE WHEN ? THEN CURRENT_TIMESTAMP END, CURRENT_TIMESTAMP
+		FROM feeds
+		WHERE feeds.id IN (%s)
+		AND feeds.feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)
+		ON CONFLICT(user_id, item_id) DO UPDATE SET
+			is_read = excluded.is_read,
+			read_at = excluded.read_at,
+			updated_at = excluded.updated_at`, placeholders)
+
+	args := make([]any, 0, len(itemIDs)+4)
+	args = append(args, userID, read, read)
+
+	for _, id := range itemIDs {
+		args = append(args, id)
+	}
+
+	args = append(args, userID)
+
+	if _, err := DB.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to set read state for user id %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// SetItemStarred stars or unstars a feed item for the user. Only items from the user's
+// subscriptions can be starred, but unstarring works even after the feed was removed.
+func SetItemStarred(userID, itemID int, starred bool) error {
+	var err error
+
+	if starred {
+		_, err = DB.Exec(`INSERT INTO user_item_states (user_id, item_id, starred, starred_at, updated_at)
+			SELECT ?, feeds.id, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+			FROM feeds
+			WHERE feeds.id = ?
+			AND feeds.feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)
+			ON CONFLICT(user_id, item_id) DO UPDATE SET
+				starred = 1,
+				starred_at = excluded.starred_at,
+				updated_at = excluded.updated_at`, userID, itemID, userID)
+	} else {
+		_, err = DB.Exec(`UPDATE user_item_states SET starred = 0, starred_at = NULL, updated_at = CURRENT_TIMESTAMP
+			WHERE user_id = ? AND item_id = ?`, userID, itemID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to set starred state of item id %d for user id %d: %w", itemID, userID, err)
+	}
+
+	return nil
+}
+
+// MarkItemsReadBefore marks every item from feedUrls published before the given time as read
+// and returns how many items changed state.
+func MarkItemsReadBefore(userID int, feedUrls []string, before time.Time) (int64, error) {
+	if len(feedUrls) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.Repeat(",?", len(feedUrls))[1:]
+
+	query := fmt.Sprintf(`INSERT INTO user_item_states (user_id, item_id, is_read, read_at, updated_at)
+		SELECT ?, feeds.id, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM feeds
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE feeds.feed_url IN (%s)
+		AND feeds.feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)
+		AND datetime(feeds.date) < datetime(?)
+		AND COALESCE(user_item_states.is_read, 0) = 0
+		ON CONFLICT(user_id, item_id) DO UPDATE SET
+			is_read = 1,
+			read_at = excluded.read_at,
+			updated_at = excluded.updated_at`, placeholders)
+
+	args := make([]any, 0, len(feedUrls)+4)
+	args = append(args, userID, userID)
+
+	for _, u := range feedUrls {
+		args = append(args, u)
+	}
+
+	args = append(args, userID, before.Format(time.RFC3339))
+
+	res, err := DB.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark items read for user id %d: %w", userID, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
+// GetUserUnreadCounts returns the number of unread items per subscribed feed url.
+func GetUserUnreadCounts(userID int) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	rows, err := DB.Query(`SELECT feeds.feed_url, COUNT(*) FROM feeds
+		LEFT JOIN user_item_states ON user_item_states.item_id = feeds.id AND user_item_states.user_id = ?
+		WHERE feeds.feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)
+		AND COALESCE(user_item_states.is_read, 0) = 0
+		GROUP BY feeds.feed_url`, userID, userID)
+	if err != nil {
+		return counts, fmt.Errorf("failed to get unread counts for user id %d: %w", userID, err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close unread counts rows", "userID", userID, "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			feedURL string
+			count   int
+		)
+
+		if err := rows.Scan(&feedURL, &count); err != nil {
+			return counts, fmt.Errorf("failed to scan unread counts: %w", err)
+		}
+
+		counts[feedURL] = count
+	}
+
+	return counts, rows.Err()
+}
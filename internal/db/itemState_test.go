@@ -0,0 +1,238 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// setupMigratedTestDB opens an in-memory database with the full schema applied.
+func setupMigratedTestDB(t *testing.T) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in‑memory sqlite: %v", err)
+	}
+
+	// every connection to :memory: is a separate database, keep just one
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close test db: %v", err)
+		}
+	})
+
+	DB = db
+
+	if err := RunMigrations(MigrateUp, 0); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+}
+
+func insertTestItem(t *testing.T, feedURL, link string, date time.Time) int {
+	t.Helper()
+
+	res, err := DB.Exec(`INSERT INTO feeds (title, link, date, source, description, feed_url) VALUES (?, ?, ?, ?, ?, ?)`,
+		"title "+link, link, date.Format(time.RFC3339), "source", "description", feedURL)
+	if err != nil {
+		t.Fatalf("failed to insert test item: %v", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatalf("failed to get test item id: %v", err)
+	}
+
+	return int(id)
+}
+
+func TestItemReadState(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("alice", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const (
+		feedA = "https://a.example.com/rss"
+		feedB = "https://b.example.com/rss"
+	)
+
+	if err := AddUserFeed(user.ID, "A", feedA, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	now := time.Now()
+	fresh := insertTestItem(t, feedA, "https://a.example.com/1", now)
+	old := insertTestItem(t, feedA, "https://a.example.com/2", now.AddDate(0, 0, -10))
+	foreign := insertTestItem(t, feedB, "https://b.example.com/1", now)
+
+	t.Run("all unread by default", func(t *testing.T) {
+		counts, err := GetUserUnreadCounts(user.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if counts[feedA] != 2 || len(counts) != 1 {
+			t.Fatalf("unexpected unread counts: %v", counts)
+		}
+	})
+
+	t.Run("foreign items are ignored", func(t *testing.T) {
+		if err := SetItemsReadState(user.ID, []int{foreign}, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var n int
+		if err := DB.QueryRow(`SELECT COUNT(*) FROM user_item_states`).Scan(&n); err != nil {
+			t.Fatalf("failed to count states: %v", err)
+		}
+
+		if n != 0 {
+			t.Fatalf("expected no state rows for foreign item, got %d", n)
+		}
+	})
+
+	t.Run("mark older as read", func(t *testing.T) {
+		n, err := MarkItemsReadBefore(user.ID, []string{feedA}, now.AddDate(0, 0, -1))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n != 1 {
+			t.Fatalf("expected 1 item marked, got %d", n)
+		}
+
+		filter := models.FeedItemsFilter{FeedURLs: []string{feedA}, HideRead: true}
+
+		items, err := GetUserFeedItems(user.ID, filter, 10, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(items) != 1 || items[0].ID != fresh {
+			t.Fatalf("expected only fresh item, got %+v", items)
+		}
+
+		total, err := GetTotalUserFeedItemsCount(user.ID, filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if total != 1 {
+			t.Fatalf("expected total 1, got %d", total)
+		}
+	})
+
+	t.Run("toggle back to unread", func(t *testing.T) {
+		if err := SetItemsReadState(user.ID, []int{old}, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		counts, err := GetUserUnreadCounts(user.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if counts[feedA] != 2 {
+			t.Fatalf("expected 2 unread items, got %v", counts)
+		}
+	})
+}
+
+func TestStarredItems(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("bob", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("bob")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := AddUserFeed(user.ID, "A", feedURL, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	starred := insertTestItem(t, feedURL, "https://a.example.com/1", time.Now())
+	plain := insertTestItem(t, feedURL, "https://a.example.com/2", time.Now())
+
+	if err := SetItemStarred(user.ID, starred, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("starred items survive cleanup", func(t *testing.T) {
+		if _, err := DB.Exec(`DELETE FROM feeds`); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var ids []int
+
+		rows, err := DB.Query(`SELECT id FROM feeds`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids = append(ids, id)
+		}
+
+		if err := rows.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(ids) != 1 || ids[0] != starred {
+			t.Fatalf("expected only starred item %d to survive, got %v (plain %d)", starred, ids, plain)
+		}
+	})
+
+	t.Run("starred page lists items after unsubscribe", func(t *testing.T) {
+		if _, err := DB.Exec(`DELETE FROM user_feeds WHERE user_id = ?`, user.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		filter := models.FeedItemsFilter{StarredOnly: true}
+
+		items, err := GetUserFeedItems(user.ID, filter, 10, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(items) != 1 || !items[0].IsStarred {
+			t.Fatalf("expected one starred item, got %+v", items)
+		}
+	})
+
+	t.Run("unstar", func(t *testing.T) {
+		if err := SetItemStarred(user.ID, starred, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		total, err := GetTotalUserFeedItemsCount(user.ID, models.FeedItemsFilter{StarredOnly: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if total != 0 {
+			t.Fatalf("expected no starred items, got %d", total)
+		}
+	})
+}
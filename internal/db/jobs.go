@@ -0,0 +1,235 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// CreateJob queues a job of the user fetching the given feed urls and returns its id.
+func CreateJob(userID int, kind string, feedURLs []string, now time.Time) (int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	res, err := tx.Exec(`INSERT INTO jobs (user_id, kind, status, created_at) VALUES (?, ?, ?, ?)`,
+		userID, kind, models.JobQueued, now.Unix())
+	if err != nil {
+		_ = tx.Rollback()
+
+		return 0, fmt.Errorf("failed to create %s job for user id %d: %w", kind, userID, err)
+	}
+
+	jobID, err := res.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback()
+
+		return 0, fmt.Errorf("failed to get created job id: %w", err)
+	}
+
+	for _, feedURL := range feedURLs {
+		_, err := tx.Exec(`INSERT INTO job_feeds (job_id, feed_url, status) VALUES (?, ?, ?)
+			ON CONFLICT(job_id, feed_url) DO NOTHING`, jobID, feedURL, models.JobQueued)
+		if err != nil {
+			_ = tx.Rollback()
+
+			return 0, fmt.Errorf("failed to add feed %s to job %d: %w", feedURL, jobID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit job %d: %w", jobID, err)
+	}
+
+	return jobID, nil
+}
+
+// GetUserActiveJobID returns id of the queued or running job of the kind, zero if the user has none.
+func GetUserActiveJobID(userID int, kind string) (int64, error) {
+	var jobID int64
+
+	err := DB.QueryRow(`SELECT id FROM jobs WHERE user_id = ? AND kind = ? AND status IN (?, ?)
+		ORDER BY id DESC LIMIT 1`, userID, kind, models.JobQueued, models.JobRunning).Scan(&jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active %s job of user id %d: %w", kind, userID, err)
+	}
+
+	return jobID, nil
+}
+
+// GetUserJob returns the job of the user with progress of all its feeds, ErrJobNotFound if there is no such job.
+func GetUserJob(userID int, jobID int64) (models.Job, error) {
+	var (
+		job                              models.Job
+		createdAt, startedAt, finishedAt int64
+	)
+
+	err := DB.QueryRow(`SELECT id, user_id, kind, status, created_at, COALESCE(started_at, 0), COALESCE(finished_at, 0)
+		FROM jobs WHERE id = ? AND user_id = ?`, jobID, userID).
+		Scan(&job.ID, &job.UserID, &job.Kind, &job.Status, &createdAt, &startedAt, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, ErrJobNotFound
+	}
+
+	if err != nil {
+		return job, fmt.Errorf("failed to get job %d of user id %d: %w", jobID, userID, err)
+	}
+
+	job.CreatedAt = unixOrZero(createdAt)
+	job.StartedAt = unixOrZero(startedAt)
+	job.FinishedAt = unixOrZero(finishedAt)
+
+	job.Feeds, err = getJobFeeds(`SELECT job_feeds.feed_url, COALESCE(user_feeds.title, ''), job_feeds.status,
+		job_feeds.new_items, job_feeds.error, COALESCE(job_feeds.finished_at, 0)
+		FROM job_feeds
+		LEFT JOIN user_feeds ON user_feeds.feed_url = job_feeds.feed_url AND user_feeds.user_id = ?
+		WHERE job_feeds.job_id = ?
+		ORDER BY job_feeds.rowid`, userID, jobID)
+
+	return job, err
+}
+
+// ClaimNextJob marks the oldest queued job running and returns it with the feeds not finished yet.
+// Returns false if the queue is empty.
+func ClaimNextJob(now time.Time) (models.Job, bool, error) {
+	var job models.Job
+
+	err := DB.QueryRow(`UPDATE jobs SET status = ?, started_at = COALESCE(started_at, ?)
+		WHERE id = (SELECT id FROM jobs WHERE status = ? ORDER BY id LIMIT 1)
+		RETURNING id, user_id, kind, status`, models.JobRunning, now.Unix(), models.JobQueued).
+		Scan(&job.ID, &job.UserID, &job.Kind, &job.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, false, nil
+	}
+
+	if err != nil {
+		return job, false, fmt.Errorf("failed to claim next job: %w", err)
+	}
+
+	job.StartedAt = now
+
+	job.Feeds, err = getJobFeeds(`SELECT feed_url, '', status, new_items, error, COALESCE(finished_at, 0)
+		FROM job_feeds WHERE job_id = ? AND status IN (?, ?)
+		ORDER BY rowid`, job.ID, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return job, false, err
+	}
+
+	return job, true, nil
+}
+
+func getJobFeeds(query string, args ...any) ([]models.JobFeed, error) {
+	var feeds []models.JobFeed
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job feeds: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close job feeds rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			feed       models.JobFeed
+			finishedAt int64
+		)
+
+		err := rows.Scan(&feed.FeedURL, &feed.Title, &feed.Status, &feed.NewItems, &feed.Error, &finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job feed: %w", err)
+		}
+
+		feed.FinishedAt = unixOrZero(finishedAt)
+
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+// SetJobFeedRunning marks the feed of the job as being fetched right now.
+func SetJobFeedRunning(jobID int64, feedURL string) error {
+	_, err := DB.Exec(`UPDATE job_feeds SET status = ? WHERE job_id = ? AND feed_url = ?`,
+		models.JobRunning, jobID, feedURL)
+	if err != nil {
+		return fmt.Errorf("failed to set feed %s of job %d running: %w", feedURL, jobID, err)
+	}
+
+	return nil
+}
+
+// SetJobFeedFinished records the outcome of the feed fetch, fetchErr is empty unless status is failed.
+func SetJobFeedFinished(jobID int64, feedURL, status string, newItems int, fetchErr string, now time.Time) error {
+	_, err := DB.Exec(`UPDATE job_feeds SET status = ?, new_items = ?, error = ?, finished_at = ?
+		WHERE job_id = ? AND feed_url = ?`, status, newItems, fetchErr, now.Unix(), jobID, feedURL)
+	if err != nil {
+		return fmt.Errorf("failed to set feed %s of job %d finished: %w", feedURL, jobID, err)
+	}
+
+	return nil
+}
+
+// FinishJob marks the job done. Feeds left unfinished, e.g. skipped after a timeout, are marked failed.
+func FinishJob(jobID int64, now time.Time) error {
+	_, err := DB.Exec(`UPDATE job_feeds SET status = ?, error = 'not fetched in time', finished_at = ?
+		WHERE job_id = ? AND status IN (?, ?)`, models.JobFailed, now.Unix(), jobID, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to finish feeds of job %d: %w", jobID, err)
+	}
+
+	_, err = DB.Exec(`UPDATE jobs SET status = ?, finished_at = ? WHERE id = ?`, models.JobDone, now.Unix(), jobID)
+	if err != nil {
+		return fmt.Errorf("failed to finish job %d: %w", jobID, err)
+	}
+
+	return nil
+}
+
+// RequeueInterruptedJobs puts jobs left running by a previous process back to the queue,
+// feeds already finished by them are not fetched again.
+func RequeueInterruptedJobs() error {
+	_, err := DB.Exec(`UPDATE job_feeds SET status = ? WHERE status = ?`, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to requeue interrupted job feeds: %w", err)
+	}
+
+	_, err = DB.Exec(`UPDATE jobs SET status = ? WHERE status = ?`, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to requeue interrupted jobs: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteJobsFinishedBefore removes finished jobs with their feeds and returns how many jobs were removed.
+func DeleteJobsFinishedBefore(before time.Time) (int64, error) {
+	_, err := DB.Exec(`DELETE FROM job_feeds WHERE job_id IN
+		(SELECT id FROM jobs WHERE status = ? AND finished_at < ?)`, models.JobDone, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete feeds of finished jobs: %w", err)
+	}
+
+	res, err := DB.Exec(`DELETE FROM jobs WHERE status = ? AND finished_at < ?`, models.JobDone, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	return n, nil
+}
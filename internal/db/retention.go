@@ -0,0 +1,310 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// pruneHistoryDays - pruning results older than that are dropped
+const pruneHistoryDays = 90
+
+// GetFeedRetentions - returns all per-feed retention overrides
+func GetFeedRetentions() ([]models.FeedRetention, error) {
+	var retentions []models.FeedRetention
+
+	rows, err := DB.Query(`SELECT feed_url, max_age_days, max_items FROM feed_retention ORDER BY feed_url`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed retentions: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close feed retention rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			retention        models.FeedRetention
+			maxAge, maxItems sql.NullInt64
+		)
+
+		if err := rows.Scan(&retention.FeedURL, &maxAge, &maxItems); err != nil {
+			return nil, fmt.Errorf("failed to scan feed retention: %w", err)
+		}
+
+		retention.MaxAgeDays = nullIntPtr(maxAge)
+		retention.MaxItems = nullIntPtr(maxItems)
+
+		retentions = append(retentions, retention)
+	}
+
+	return retentions, rows.Err()
+}
+
+// SetFeedRetention - saves retention override of the feed, both limits nil remove the override
+func SetFeedRetention(feedURL string, maxAgeDays, maxItems *int) error {
+	if maxAgeDays == nil && maxItems == nil {
+		return DeleteFeedRetention(feedURL)
+	}
+
+	_, err := DB.Exec(`INSERT INTO feed_retention (feed_url, max_age_days, max_items, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(feed_url) DO UPDATE SET
+			max_age_days = excluded.max_age_days,
+			max_items = excluded.max_items,
+			updated_at = excluded.updated_at`, feedURL, maxAgeDays, maxItems)
+	if err != nil {
+		return fmt.Errorf("failed to set retention of feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+// DeleteFeedRetention - removes retention override of the feed, so the global policy applies
+func DeleteFeedRetention(feedURL string) error {
+	if _, err := DB.Exec(`DELETE FROM feed_retention WHERE feed_url = ?`, feedURL); err != nil {
+		return fmt.Errorf("failed to delete retention of feed %s: %w", feedURL, err)
+	}
+
+	return nil
+}
+
+// PruneFeedItems deletes feed items exceeding the global policy or the feed override and records
+// how many rows each policy removed. Starred items are never deleted, as well as items
+// the feed still publishes, otherwise they would come back as new on the next fetch.
+func PruneFeedItems(global models.RetentionPolicy, now time.Time) ([]models.PruneResult, error) {
+	overrides, err := GetFeedRetentions()
+	if err != nil {
+		return nil, err
+	}
+
+	overrideByURL := make(map[string]models.FeedRetention, len(overrides))
+	for _, override := range overrides {
+		overrideByURL[override.FeedURL] = override
+	}
+
+	feeds, err := storedFeedItemCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	type resultKey struct{ policy, feedURL string }
+
+	removed := make(map[resultKey]int64)
+	order := make([]resultKey, 0)
+
+	record := func(policy, feedURL string, n int64) {
+		key := resultKey{policy: policy, feedURL: feedURL}
+		if _, ok := removed[key]; !ok {
+			order = append(order, key)
+		}
+
+		removed[key] += n
+	}
+
+	if global.MaxAgeDays > 0 {
+		record(models.RetentionMaxAge, "", 0)
+	}
+
+	if global.MaxItems > 0 {
+		record(models.RetentionMaxItems, "", 0)
+	}
+
+	for feedURL, published := range feeds {
+		override := overrideByURL[feedURL]
+		policy := override.Apply(global)
+
+		if policy.MaxAgeDays > 0 {
+			cutoff := now.AddDate(0, 0, -policy.MaxAgeDays).Unix()
+
+			n, err := execRowsAffected(`DELETE FROM feeds WHERE feed_url = ? AND COALESCE(first_seen_at, 0) < ?
+				AND id NOT IN (`+newestFeedItemsQuery+`)
+				AND id NOT IN (SELECT item_id FROM user_item_states WHERE starred = 1)`,
+				feedURL, cutoff, feedURL, published)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prune old items of feed %s: %w", feedURL, err)
+			}
+
+			record(models.RetentionMaxAge, overrideScope(feedURL, override.MaxAgeDays), n)
+		}
+
+		if policy.MaxItems > 0 {
+			n, err := execRowsAffected(`DELETE FROM feeds WHERE feed_url = ?
+				AND id NOT IN (`+newestFeedItemsQuery+`)
+				AND id NOT IN (SELECT item_id FROM user_item_states WHERE starred = 1)`,
+				feedURL, feedURL, max(policy.MaxItems, published))
+			if err != nil {
+				return nil, fmt.Errorf("failed to prune extra items of feed %s: %w", feedURL, err)
+			}
+
+			record(models.RetentionMaxItems, overrideScope(feedURL, override.MaxItems), n)
+		}
+	}
+
+	_, err = DB.Exec(`DELETE FROM user_item_states WHERE starred = 0 AND item_id NOT IN (SELECT id FROM feeds)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete states of pruned items: %w", err)
+	}
+
+	results := make([]models.PruneResult, 0, len(order))
+	for _, key := range order {
+		results = append(results, models.PruneResult{Policy: key.policy, FeedURL: key.feedURL, Removed: removed[key]})
+	}
+
+	if err := savePruneResults(results, now); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// GetPruneStats - returns accumulated pruning results of every policy, global ones first
+func GetPruneStats() ([]models.PruneStats, error) {
+	var stats []models.PruneStats
+
+	rows, err := DB.Query(`SELECT policy, feed_url, SUM(removed), MAX(run_at),
+		(SELECT last.removed FROM prune_runs AS last
+			WHERE last.policy = prune_runs.policy AND last.feed_url = prune_runs.feed_url
+			ORDER BY last.run_at DESC, last.id DESC LIMIT 1)
+		FROM prune_runs
+		GROUP BY policy, feed_url
+		ORDER BY feed_url != '', feed_url, policy`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prune stats: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close prune stats rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			stat      models.PruneStats
+			lastRunAt int64
+		)
+
+		if err := rows.Scan(&stat.Policy, &stat.FeedURL, &stat.TotalRemoved, &lastRunAt, &stat.LastRemoved); err != nil {
+			return nil, fmt.Errorf("failed to scan prune stats: %w", err)
+		}
+
+		stat.LastRunAt = unixOrZero(lastRunAt)
+
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// newestFeedItemsQuery selects ids of the newest items of a feed, takes feed url and limit
+const newestFeedItemsQuery = `SELECT id FROM feeds WHERE feed_url = ?
+	ORDER BY COALESCE(first_seen_at, 0) DESC, id DESC LIMIT ?`
+
+// storedFeedItemCounts returns every feed url having stored items with the number of items it published last time.
+func storedFeedItemCounts() (map[string]int, error) {
+	rows, err := DB.Query(`SELECT stored.feed_url, COALESCE(feed_states.item_count, 0)
+		FROM (SELECT DISTINCT feed_url FROM feeds) AS stored
+		LEFT JOIN feed_states ON feed_states.feed_url = stored.feed_url`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored feeds: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close stored feeds rows", "error", closeErr)
+		}
+	}()
+
+	feeds := make(map[string]int)
+
+	for rows.Next() {
+		var (
+			feedURL   sql.NullString
+			published int
+		)
+
+		if err := rows.Scan(&feedURL, &published); err != nil {
+			return nil, fmt.Errorf("failed to scan stored feeds: %w", err)
+		}
+
+		if feedURL.Valid {
+			feeds[feedURL.String] = published
+		}
+	}
+
+	return feeds, rows.Err()
+}
+
+func savePruneResults(results []models.PruneResult, runAt time.Time) error {
+	for _, result := range results {
+		_, err := DB.Exec(`INSERT INTO prune_runs (run_at, policy, feed_url, removed) VALUES (?, ?, ?, ?)`,
+			runAt.Unix(), result.Policy, result.FeedURL, result.Removed)
+		if err != nil {
+			return fmt.Errorf("failed to save prune result: %w", err)
+		}
+	}
+
+	_, err := DB.Exec(`DELETE FROM prune_runs WHERE run_at < ?`, runAt.AddDate(0, 0, -pruneHistoryDays).Unix())
+	if err != nil {
+		return fmt.Errorf("failed to trim prune history: %w", err)
+	}
+
+	return nil
+}
+
+// overrideScope returns feed url if its override sets the limit, empty string for the global policy.
+func overrideScope(feedURL string, limit *int) string {
+	if limit == nil {
+		return ""
+	}
+
+	return feedURL
+}
+
+func execRowsAffected(query string, args ...any) (int64, error) {
+	res, err := DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+
+	n := int(v.Int64)
+
+	return &n
+}
+
+// GetSubscribedFeedURLs - returns every distinct feed url someone is subscribed to
+func GetSubscribedFeedURLs() ([]string, error) {
+	var urls []string
+
+	rows, err := DB.Query(`SELECT DISTINCT feed_url FROM user_feeds ORDER BY feed_url`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribed feed urls: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close subscribed feed urls rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var feedURL string
+		if err := rows.Scan(&feedURL); err != nil {
+			return nil, fmt.Errorf("failed to scan subscribed feed url: %w", err)
+		}
+
+		urls = append(urls, feedURL)
+	}
+
+	return urls, rows.Err()
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func insertSeenTestItem(t *testing.T, feedURL, link string, seenAt time.Time) int {
+	t.Helper()
+
+	id := insertTestItem(t, feedURL, link, seenAt)
+
+	if _, err := DB.Exec(`UPDATE feeds SET first_seen_at = ? WHERE id = ?`, seenAt.Unix(), id); err != nil {
+		t.Fatalf("failed to set first seen time: %v", err)
+	}
+
+	return id
+}
+
+func feedItemExists(t *testing.T, id int) bool {
+	t.Helper()
+
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM feeds WHERE id = ?`, id).Scan(&count); err != nil {
+		t.Fatalf("failed to count items: %v", err)
+	}
+
+	return count > 0
+}
+
+func TestPruneFeedItems(t *testing.T) {
+	setupMigratedTestDB(t)
+
+	if err := RegisterUser("alice", "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := GetUserInfoByUsername("alice")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	const (
+		feedA = "https://a.example.com/rss"
+		feedB = "https://b.example.com/rss"
+	)
+
+	if err := AddUserFeed(user.ID, "A", feedA, ""); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	now := time.Now()
+
+	fresh := insertSeenTestItem(t, feedA, "https://a.example.com/1", now.AddDate(0, 0, -1))
+	old := insertSeenTestItem(t, feedA, "https://a.example.com/2", now.AddDate(0, 0, -40))
+	oldStarred := insertSeenTestItem(t, feedA, "https://a.example.com/3", now.AddDate(0, 0, -50))
+
+	if err := SetItemStarred(user.ID, oldStarred, true); err != nil {
+		t.Fatalf("failed to star item: %v", err)
+	}
+
+	if err := SetItemsReadState(user.ID, []int{old}, true); err != nil {
+		t.Fatalf("failed to mark item read: %v", err)
+	}
+
+	// feed B still publishes its two newest items
+	var feedBItems []int
+	for i := range 4 {
+		feedBItems = append(feedBItems, insertSeenTestItem(t, feedB, "https://b.example.com/"+string(rune('a'+i)), now.AddDate(0, 0, -60-i)))
+	}
+
+	if err := SetFeedFetchSucceeded(feedB, now, 200, 2); err != nil {
+		t.Fatalf("failed to set feed state: %v", err)
+	}
+
+	results, err := PruneFeedItems(models.RetentionPolicy{MaxAgeDays: 30}, now)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+
+	if !feedItemExists(t, fresh) || !feedItemExists(t, oldStarred) {
+		t.Fatal("expected fresh and starred items to be kept")
+	}
+
+	if feedItemExists(t, old) {
+		t.Fatal("expected old item to be removed")
+	}
+
+	for i, id := range feedBItems {
+		if want := i < 2; feedItemExists(t, id) != want {
+			t.Fatalf("item %d of feed B: exists = %v, want %v", i, !want, want)
+		}
+	}
+
+	if len(results) != 1 || results[0].Policy != models.RetentionMaxAge || results[0].FeedURL != "" || results[0].Removed != 3 {
+		t.Fatalf("unexpected prune results: %+v", results)
+	}
+
+	var states int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM user_item_states WHERE item_id = ?`, old).Scan(&states); err != nil {
+		t.Fatalf("failed to count item states: %v", err)
+	}
+
+	if states != 0 {
+		t.Fatal("expected state of removed item to be deleted")
+	}
+
+	t.Run("feed override", func(t *testing.T) {
+		maxItems := 1
+		if err := SetFeedRetention(feedA, nil, &maxItems); err != nil {
+			t.Fatalf("failed to set feed retention: %v", err)
+		}
+
+		newer := insertSeenTestItem(t, feedA, "https://a.example.com/4", now)
+
+		results, err := PruneFeedItems(models.RetentionPolicy{MaxAgeDays: 30}, now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+
+		if !feedItemExists(t, newer) || feedItemExists(t, fresh) || !feedItemExists(t, oldStarred) {
+			t.Fatal("expected only the newest and starred items of feed A to be kept")
+		}
+
+		var found bool
+		for _, result := range results {
+			if result.Policy == models.RetentionMaxItems && result.FeedURL == feedA {
+				found = result.Removed == 1
+			}
+		}
+
+		if !found {
+			t.Fatalf("expected one item removed by feed A override, got %+v", results)
+		}
+
+		overrides, err := GetFeedRetentions()
+		if err != nil {
+			t.Fatalf("failed to get feed retentions: %v", err)
+		}
+
+		if len(overrides) != 1 || overrides[0].MaxAgeDays != nil || overrides[0].MaxItems == nil || *overrides[0].MaxItems != 1 {
+			t.Fatalf("unexpected overrides: %+v", overrides)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := GetPruneStats()
+		if err != nil {
+			t.Fatalf("failed to get prune stats: %v", err)
+		}
+
+		if len(stats) != 2 {
+			t.Fatalf("expected global and feed A stats, got %+v", stats)
+		}
+
+		global := stats[0]
+		if global.FeedURL != "" || global.Policy != models.RetentionMaxAge || global.TotalRemoved != 3 || global.LastRemoved != 0 {
+			t.Fatalf("unexpected global stats: %+v", global)
+		}
+
+		if stats[1].FeedURL != feedA || stats[1].TotalRemoved != 1 {
+			t.Fatalf("unexpected feed stats: %+v", stats[1])
+		}
+	})
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"log/slog"
-	"strconv"
 	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
 )
 
 const defaultRefreshInterval = 60 // in minutes
@@ -44,59 +46,54 @@ func SetUserRefreshInterval(userID int, intervalMinutes int) error {
 	return nil
 }
 
-// SetLastUpdateTS - sets when last fetch is performed
-func SetLastUpdateTS(userID int, interval int) error {
-	now := time.Now()
-	next := now.Add(time.Duration(interval) * time.Minute).Unix()
-
-	_, err := DB.Exec(`INSERT OR REPLACE INTO user_refresh_settings 
-	(user_id, last_update_ts, next_update_ts, interval_minutes) VALUES (?, ?, ?, ?)`,
-		userID, now.Unix(), next, interval)
+// SetUserFeedInterval - sets refresh interval of the user subscription, nil follows the user's interval
+func SetUserFeedInterval(userID int, feedID string, intervalMinutes *int) error {
+	_, err := DB.Exec(`UPDATE user_feeds SET interval_minutes = ? WHERE id = ? AND user_id = ?`,
+		intervalMinutes, feedID, userID)
 	if err != nil {
-		slog.Error("failed to update last_update_ts in user_refresh_settings", "error", err)
-
-		return err
+		return fmt.Errorf("failed to set refresh interval of feed id %s for user id %d: %w", feedID, userID, err)
 	}
 
 	return nil
 }
 
-// GetLastUpdateTS - return ts when last fetch is performed
-func GetNextUpdateTS(userID int) (time.Time, error) {
-	var timestampStr sql.NullString
-
-	query := "SELECT next_update_ts FROM user_refresh_settings WHERE user_id = ?"
+// GetFeedSchedules returns fetch schedule of every distinct subscribed feed url.
+// Subscription interval overrides the interval of its user. Dead feeds and feeds
+// whose subscriptions all have autorefresh disabled are not scheduled.
+func GetFeedSchedules() ([]models.FeedSchedule, error) {
+	return getFeedSchedules(0)
+}
 
-	err := DB.QueryRow(query, userID).Scan(&timestampStr)
+// GetNextUpdateTS - returns ts of the nearest scheduled fetch among user feeds,
+// zero time means that some feeds are waiting for the first fetch
+func GetNextUpdateTS(userID int) (time.Time, error) {
+	schedules, err := getFeedSchedules(userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return time.Time{}, nil
-		}
-
 		return time.Time{}, err
 	}
 
-	if !timestampStr.Valid {
-		return time.Time{}, nil
-	}
+	var next time.Time
 
-	ts, err := strconv.ParseInt(timestampStr.String, 10, 64)
-	if err != nil {
-		return time.Time{}, err
-	}
+	for i, schedule := range schedules {
+		nextFetch := schedule.NextFetchAt()
+		if nextFetch.IsZero() {
+			return time.Time{}, nil
+		}
 
-	timestamp := time.Unix(ts, 0)
+		if i == 0 || nextFetch.Before(next) {
+			next = nextFetch
+		}
+	}
 
-	return timestamp, nil
+	return next, nil
 }
 
-// GetLastUpdateTS - returns ts when last update was performed
+// GetLastUpdateTS - returns ts when any of user feeds was fetched last time
 func GetLastUpdateTS(userID int) (time.Time, error) {
-	var timestampStr sql.NullString
+	var ts sql.NullInt64
 
-	query := "SELECT last_update_ts FROM user_refresh_settings WHERE user_id = ?"
-
-	err := DB.QueryRow(query, userID).Scan(&timestampStr)
+	err := DB.QueryRow(`SELECT MAX(last_fetch_at) FROM feed_states
+		WHERE feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)`, userID).Scan(&ts)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return time.Time{}, nil
@@ -105,16 +102,75 @@ func GetLastUpdateTS(userID int) (time.Time, error) {
 		return time.Time{}, err
 	}
 
-	if !timestampStr.Valid {
+	if !ts.Valid {
 		return time.Time{}, nil
 	}
 
-	ts, err := strconv.ParseInt(timestampStr.String, 10, 64)
+	return time.Unix(ts.Int64, 0), nil
+}
+
+// getFeedSchedules - returns feed schedules, limited to feeds of the given user if userID is not 0
+func getFeedSchedules(userID int) ([]models.FeedSchedule, error) {
+	var schedules []models.FeedSchedule
+
+	query := `SELECT user_feeds.feed_url,
+		MIN(COALESCE(user_feeds.interval_minutes, user_refresh_settings.interval_minutes, ?)),
+		MAX(COALESCE(feed_states.last_fetch_at, 0)),
+		MAX(COALESCE(feed_states.consecutive_failures, 0)),
+		MAX(COALESCE(feed_states.retry_after_at, 0)),
+		MAX(COALESCE(feed_states.hint_interval_minutes, 0)),
+		MAX(COALESCE(feed_states.skip_hours, '')),
+		MAX(COALESCE(feed_states.skip_days, ''))
+		FROM user_feeds
+		LEFT JOIN user_refresh_settings ON user_refresh_settings.user_id = user_feeds.user_id
+		LEFT JOIN feed_states ON feed_states.feed_url = user_feeds.feed_url
+		WHERE COALESCE(user_feeds.interval_minutes, user_refresh_settings.interval_minutes, ?) > 0
+		AND COALESCE(feed_states.dead, 0) = 0`
+	args := []any{defaultRefreshInterval, defaultRefreshInterval}
+
+	if userID != 0 {
+		query += ` AND user_feeds.feed_url IN (SELECT feed_url FROM user_feeds WHERE user_id = ?)`
+		args = append(args, userID)
+	}
+
+	query += ` GROUP BY user_feeds.feed_url`
+
+	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return time.Time{}, err
+		slog.Error("failed to get feed schedules", "error", err)
+
+		return nil, fmt.Errorf("failed to get feed schedules: %w", err)
 	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.Error("failed to close feed schedules rows", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			schedule                  models.FeedSchedule
+			lastFetchAt, retryAfterAt int64
+			skipHours, skipDays       string
+		)
+
+		err := rows.Scan(&schedule.FeedURL, &schedule.IntervalMinutes, &lastFetchAt,
+			&schedule.ConsecutiveFailures, &retryAfterAt,
+			&schedule.Hints.MinIntervalMinutes, &skipHours, &skipDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed schedules: %w", err)
+		}
 
-	timestamp := time.Unix(ts, 0)
+		schedule.LastFetchAt = unixOrZero(lastFetchAt)
+		schedule.RetryAfterAt = unixOrZero(retryAfterAt)
+		schedule.Hints.SkipHours = splitInts(skipHours)
+
+		for _, day := range splitInts(skipDays) {
+			schedule.Hints.SkipDays = append(schedule.Hints.SkipDays, time.Weekday(day))
+		}
+
+		schedules = append(schedules, schedule)
+	}
 
-	return timestamp, nil
+	return schedules, rows.Err()
 }
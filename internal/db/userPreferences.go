@@ -0,0 +1,40 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// GetUserTimelineSort - returns how user timeline is sorted, publish date if nothing is chosen
+func GetUserTimelineSort(userID int) (string, error) {
+	var sortBy string
+
+	err := DB.QueryRow(`SELECT timeline_sort FROM user_preferences WHERE user_id = ?`, userID).Scan(&sortBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SortByPublished, nil
+		}
+
+		return "", fmt.Errorf("failed to get timeline sort of user %d: %w", userID, err)
+	}
+
+	return sortBy, nil
+}
+
+// SetUserTimelineSort - saves how user timeline is sorted
+func SetUserTimelineSort(userID int, sortBy string) error {
+	if sortBy != models.SortByPublished && sortBy != models.SortByArrival {
+		return fmt.Errorf("unknown timeline sort %q", sortBy)
+	}
+
+	_, err := DB.Exec(`INSERT INTO user_preferences (user_id, timeline_sort) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET timeline_sort = excluded.timeline_sort`, userID, sortBy)
+	if err != nil {
+		return fmt.Errorf("failed to set timeline sort of user %d: %w", userID, err)
+	}
+
+	return nil
+}
@@ -1,76 +1,46 @@
 package feeder
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
 )
 
-// StartAutoRefresh starts the auto-refresh service for all users
+// StartAutoRefresh starts the auto-refresh service for all subscribed feeds
 func StartAutoRefresh() {
 	ticker := time.NewTicker(1 * time.Minute) // Check every minute
 	defer ticker.Stop()
 
 	for range ticker.C {
 		slog.Info("updating user feeds check")
-		refreshAllUsersFeeds()
+		refreshDueFeeds()
 	}
 }
 
-// refreshAllUsersFeeds refreshes feeds for all users based on their settings
-func refreshAllUsersFeeds() {
-	users, err := db.GetUsers()
+// refreshDueFeeds fetches every distinct feed url whose schedule is due, so a feed
+// shared by several users is downloaded only once per its shortest subscriber interval
+func refreshDueFeeds() {
+	schedules, err := db.GetFeedSchedules()
 	if err != nil {
-		slog.Error("failed to get all users", "error", err)
+		slog.Error("failed to get feed schedules", "error", err)
 
 		return
 	}
 
-	for _, user := range users {
-		interval, err := db.GetUserRefreshInterval(user.ID)
-		if err != nil {
-			slog.Error("failed to get refresh interval for user", "user_id", user.ID, "error", err)
+	now := time.Now()
+	due := make([]string, 0, len(schedules))
 
+	for _, schedule := range schedules {
+		if !schedule.IsDue(now) {
 			continue
 		}
 
-		if interval == 0 {
-			slog.Info("autofetch is disabled", "user id", user.ID, "user name", user.Username)
+		slog.Info("updating feed with autorefresh", "url", schedule.FeedURL, "interval", schedule.IntervalMinutes)
 
-			continue
-		}
-
-		nextUpdateTS, err := db.GetNextUpdateTS(user.ID)
-		if err != nil {
-			slog.Error("failed to get next update TS", "error", err)
-
-			continue
-		}
-
-		shouldUpdate := nextUpdateTS.IsZero() || time.Now().After(nextUpdateTS)
-
-		if !shouldUpdate {
-			continue
-		}
-
-		slog.Info("updating user feeds with autorefresh", "user id", user.ID, "user name", user.Username)
-
-		feedUrls, err := db.GetUserFeedUrls(user.ID)
-		if err != nil {
-			slog.Error("failed to get user feeds", "user_id", user.ID, "error", err)
-
-			continue
-		}
-
-		// Only fetch if user has feeds
-		if len(feedUrls) > 0 {
-			FetchAndSaveFeeds(feedUrls)
-		}
-
-		err = db.SetLastUpdateTS(user.ID, interval)
-		if err != nil {
-			slog.Error("failed to update last_update_ts", "error", err)
-		}
+		due = append(due, schedule.FeedURL)
 	}
+
+	FetchAndSaveFeeds(context.Background(), due)
 }
@@ -0,0 +1,80 @@
+package feeder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+)
+
+func addTestUser(t *testing.T, username string, interval int, feeds ...string) int {
+	t.Helper()
+
+	if err := db.RegisterUser(username, "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := db.GetUserInfoByUsername(username)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	if err := db.SetUserRefreshInterval(user.ID, interval); err != nil {
+		t.Fatalf("failed to set refresh interval: %v", err)
+	}
+
+	for _, feed := range feeds {
+		if err := db.AddUserFeed(user.ID, "", feed, ""); err != nil {
+			t.Fatalf("failed to add feed: %v", err)
+		}
+	}
+
+	return user.ID
+}
+
+func TestRefreshDueFeeds_FetchesSharedFeedOnce(t *testing.T) {
+	setupTestDB(t)
+
+	var mu sync.Mutex
+
+	hits := make(map[string]int)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	shared := srv.URL + "/shared"
+	disabled := srv.URL + "/disabled"
+
+	addTestUser(t, "alice", 60, shared)
+	addTestUser(t, "bob", 15, shared)
+	addTestUser(t, "carol", 0, disabled)
+
+	refreshDueFeeds()
+	refreshDueFeeds()
+
+	if hits["/shared"] != 1 {
+		t.Fatalf("expected shared feed to be fetched once, got %d", hits["/shared"])
+	}
+
+	if hits["/disabled"] != 0 {
+		t.Fatalf("expected feed with autorefresh disabled to be skipped, got %d fetches", hits["/disabled"])
+	}
+
+	schedules, err := db.GetFeedSchedules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(schedules) != 1 || schedules[0].IntervalMinutes != 15 || schedules[0].LastFetchAt.IsZero() {
+		t.Fatalf("unexpected schedules: %+v", schedules)
+	}
+}
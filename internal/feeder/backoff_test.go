@@ -0,0 +1,144 @@
+package feeder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+)
+
+func TestRetryAfterTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status int
+		value  string
+		want   time.Time
+	}{
+		{name: "seconds", status: http.StatusTooManyRequests, value: "120", want: now.Add(2 * time.Minute)},
+		{name: "http date", status: http.StatusServiceUnavailable, value: "Wed, 01 Jan 2025 13:00:00 GMT", want: now.Add(time.Hour)},
+		{name: "date in the past", status: http.StatusServiceUnavailable, value: "Wed, 01 Jan 2025 11:00:00 GMT"},
+		{name: "garbage", status: http.StatusTooManyRequests, value: "soon"},
+		{name: "ignored for other statuses", status: http.StatusNotFound, value: "120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fetchResult{statusCode: tt.status, header: http.Header{"Retry-After": []string{tt.value}}}
+
+			if got := retryAfterTime(result, now); !got.Equal(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFeedSchedule_Backoff(t *testing.T) {
+	last := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	schedule := models.FeedSchedule{IntervalMinutes: 60, LastFetchAt: last}
+
+	if next := schedule.NextFetchAt(); !next.Equal(last.Add(time.Hour)) {
+		t.Fatalf("expected plain interval without failures, got %v", next)
+	}
+
+	schedule.ConsecutiveFailures = 3
+	if next := schedule.NextFetchAt(); !next.Equal(last.Add(8 * time.Hour)) {
+		t.Fatalf("expected interval doubled per failure, got %v", next)
+	}
+
+	schedule.ConsecutiveFailures = 30
+	if next := schedule.NextFetchAt(); !next.Equal(last.Add(models.MaxFetchBackoff)) {
+		t.Fatalf("expected backoff capped, got %v", next)
+	}
+
+	schedule.RetryAfterAt = last.Add(48 * time.Hour)
+	if next := schedule.NextFetchAt(); !next.Equal(schedule.RetryAfterAt) {
+		t.Fatalf("expected Retry-After respected, got %v", next)
+	}
+}
+
+func TestFetchAndSaveFeed_MarksDeadAndRevives(t *testing.T) {
+	setupTestDB(t)
+
+	prev := utils.FeedDeadAfterFailures
+	utils.FeedDeadAfterFailures = 3
+
+	t.Cleanup(func() { utils.FeedDeadAfterFailures = prev })
+
+	failing := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	user := addTestUser(t, "dave", 60, srv.URL)
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	schedules, err := db.GetFeedSchedules()
+	if err != nil || len(schedules) != 1 {
+		t.Fatalf("failed to get schedules: %v %v", schedules, err)
+	}
+
+	if next := schedules[0].NextFetchAt(); next.Before(time.Now().Add(119 * time.Minute)) {
+		t.Fatalf("expected failed feed to be postponed by backoff, next fetch at %v", next)
+	}
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	feeds, err := db.GetUserFeeds(user)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("failed to get user feeds: %v %v", feeds, err)
+	}
+
+	if feeds[0].Health.Status() != models.FeedStatusDead || feeds[0].Health.RetryAfterAt.IsZero() {
+		t.Fatalf("expected dead feed with retry after, got %+v", feeds[0].Health)
+	}
+
+	if schedules, err := db.GetFeedSchedules(); err != nil || len(schedules) != 0 {
+		t.Fatalf("dead feed must not be scheduled, got %v %v", schedules, err)
+	}
+
+	if urls, err := db.GetUserLiveFeedUrls(user); err != nil || len(urls) != 0 {
+		t.Fatalf("dead feed must not be refreshed manually, got %v %v", urls, err)
+	}
+
+	feedURL, err := db.ReviveUserFeed(user, strconv.Itoa(feeds[0].ID))
+	if err != nil || feedURL != srv.URL {
+		t.Fatalf("failed to revive feed: %q %v", feedURL, err)
+	}
+
+	if schedules, err := db.GetFeedSchedules(); err != nil || len(schedules) != 1 || !schedules[0].IsDue(time.Now()) {
+		t.Fatalf("revived feed must be due right away, got %v %v", schedules, err)
+	}
+
+	failing = false
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	feeds, err = db.GetUserFeeds(user)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("failed to get user feeds: %v %v", feeds, err)
+	}
+
+	if health := feeds[0].Health; health.Status() != models.FeedStatusOK || !health.RetryAfterAt.IsZero() {
+		t.Fatalf("expected healthy feed after revive, got %+v", health)
+	}
+}
@@ -0,0 +1,331 @@
+package feeder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// feedMimeTypes - types of <link rel="alternate"> elements pointing to feeds
+var feedMimeTypes = map[string]struct{}{
+	"application/rss+xml":   {},
+	"application/atom+xml":  {},
+	"application/feed+json": {},
+	"application/rdf+xml":   {},
+	"application/xml":       {},
+	"text/xml":              {},
+}
+
+// wellKnownFeedPaths are probed on the site root when the page doesn't link any feed
+var wellKnownFeedPaths = []string{"/feed", "/rss", "/feed.xml", "/rss.xml", "/atom.xml", "/index.xml", "/feed.json"}
+
+const (
+	// wellKnownProbeTimeout - shared deadline for probing all well-known feed paths of a site
+	wellKnownProbeTimeout = 10 * time.Second
+	// wellKnownFeedTypes - number of feed types the parser tells apart: rss, atom and json
+	wellKnownFeedTypes = 3
+)
+
+// ErrNotFeed - the url responded with something that is neither a feed nor a web page linking feeds
+var ErrNotFeed = errors.New("not a valid RSS, Atom or JSON feed")
+
+// Probe - what a url added by the user turned out to be. A feed is downloaded and parsed once,
+// SaveProbedFeed stores it without fetching it again. Feeds lists feeds found on a web page.
+type Probe struct {
+	URL    string
+	IsFeed bool
+	Title  string
+	Feeds  []models.DiscoveredFeed
+
+	result    fetchResult
+	fetchedAt time.Time
+}
+
+// ProbeFeedURL downloads the url and parses it as a feed. Html pages are searched for
+// <link rel="alternate"> feeds, well-known feed paths of the site are probed if there are none.
+// Anything else is rejected with ErrNotFeed.
+func ProbeFeedURL(ctx context.Context, rawURL string) (Probe, error) {
+	probe := Probe{URL: rawURL}
+
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+		return probe, fmt.Errorf("%q is not a valid http(s) url", rawURL)
+	}
+
+	page, err := fetchPage(ctx, rawURL)
+	if err != nil {
+		return probe, err
+	}
+
+	contentType := page.header.Get("Content-Type")
+
+	if isHTML(page.body, contentType) {
+		probe.Feeds = linkedFeeds(page.body, contentType, pageURL)
+
+		if len(probe.Feeds) == 0 {
+			probe.Feeds = probeWellKnownFeeds(ctx, pageURL)
+		}
+
+		return probe, nil
+	}
+
+	fp, err := feedParser.Parse(bytes.NewReader(page.body))
+	if err != nil {
+		return probe, fmt.Errorf("%s is %w", rawURL, ErrNotFeed)
+	}
+
+	probe.IsFeed = true
+	probe.Title = utils.StripHTMLAndNormalizeFeedText(fp.Title)
+	probe.result = fetchResult{feed: fp, statusCode: page.statusCode, header: page.header}
+	probe.fetchedAt = time.Now()
+
+	return probe, nil
+}
+
+// SaveProbedFeed stores items of the probed feed and its fetch state, as if it was just fetched by the refresher.
+func SaveProbedFeed(ctx context.Context, probe Probe) {
+	if !probe.IsFeed {
+		return
+	}
+
+	err := db.SetFeedValidators(probe.URL, probe.result.header.Get("ETag"), probe.result.header.Get("Last-Modified"))
+	if err != nil {
+		slog.Error("failed to save feed validators", "url", probe.URL, "error", err)
+	}
+
+	saveFetchedFeed(ctx, probe.URL, probe.result, probe.fetchedAt)
+}
+
+// pageResponse - downloaded page or feed
+type pageResponse struct {
+	body       []byte
+	statusCode int
+	header     http.Header
+}
+
+// fetchPage downloads the url unconditionally. Bodies over the max response size of the shared client
+// fail with httpclient.ErrResponseTooLarge, they are never parsed truncated.
+func fetchPage(ctx context.Context, rawURL string) (pageResponse, error) {
+	var page pageResponse
+
+	timeout := utils.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return page, fmt.Errorf("failed to create request for %s: %w", rawURL, err)
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return page, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Error("failed to close page response body", "url", rawURL, "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return page, fmt.Errorf("failed to fetch %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return page, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+
+	page.body = body
+	page.statusCode = resp.StatusCode
+	page.header = resp.Header
+
+	return page, nil
+}
+
+// isHTML tells html pages from feeds by the content type, falling back to the document start
+// as plenty of servers send feeds as text/html or text/plain.
+func isHTML(body []byte, contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/xhtml+xml" {
+		return true
+	}
+
+	start := bytes.ToLower(bytes.TrimSpace(body[:min(len(body), 512)]))
+
+	if bytes.HasPrefix(start, []byte("<?xml")) || bytes.HasPrefix(start, []byte("{")) ||
+		bytes.HasPrefix(start, []byte("<rss")) || bytes.HasPrefix(start, []byte("<feed")) {
+		return false
+	}
+
+	return mediaType == "text/html" || bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.HasPrefix(start, []byte("<html"))
+}
+
+// linkedFeeds returns feeds the page advertises with <link rel="alternate">, resolved against <base> or the page url.
+func linkedFeeds(body []byte, contentType string, pageURL *url.URL) []models.DiscoveredFeed {
+	reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil
+	}
+
+	base := pageURL
+	seen := make(map[string]struct{})
+
+	var feeds []models.DiscoveredFeed
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "body" {
+			return
+		}
+
+		if node.Type == html.ElementNode {
+			attrs := make(map[string]string, len(node.Attr))
+			for _, attr := range node.Attr {
+				attrs[strings.ToLower(attr.Key)] = strings.TrimSpace(attr.Val)
+			}
+
+			switch node.Data {
+			case "base":
+				if ref, err := url.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = pageURL.ResolveReference(ref)
+				}
+			case "link":
+				if feed, ok := feedLink(attrs, base); ok {
+					if _, dup := seen[feed.URL]; !dup {
+						seen[feed.URL] = struct{}{}
+						feeds = append(feeds, feed)
+					}
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	walk(doc)
+
+	return feeds
+}
+
+func feedLink(attrs map[string]string, base *url.URL) (models.DiscoveredFeed, bool) {
+	var alternate bool
+
+	for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+		if rel == "alternate" {
+			alternate = true
+		}
+	}
+
+	feedType := strings.ToLower(attrs["type"])
+	if _, ok := feedMimeTypes[feedType]; !ok || !alternate || attrs["href"] == "" {
+		return models.DiscoveredFeed{}, false
+	}
+
+	ref, err := url.Parse(attrs["href"])
+	if err != nil {
+		return models.DiscoveredFeed{}, false
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return models.DiscoveredFeed{}, false
+	}
+
+	return models.DiscoveredFeed{URL: resolved.String(), Title: attrs["title"], Type: feedType}, true
+}
+
+// probeWellKnownFeeds tries common feed paths on the site root through the fetch pool and returns the ones
+// which parse as feeds. All paths share wellKnownProbeTimeout, for every feed type only the first path
+// in wellKnownFeedPaths order is kept and probing stops once every feed type is found.
+func probeWellKnownFeeds(ctx context.Context, pageURL *url.URL) []models.DiscoveredFeed {
+	ctx, cancel := context.WithTimeout(ctx, wellKnownProbeTimeout)
+	defer cancel()
+
+	candidates := make([]string, len(wellKnownFeedPaths))
+	positions := make(map[string]int, len(wellKnownFeedPaths))
+
+	for i, path := range wellKnownFeedPaths {
+		candidate := url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: path}
+		candidates[i] = candidate.String()
+		positions[candidates[i]] = i
+	}
+
+	var (
+		mu    sync.Mutex
+		found = make([]*models.DiscoveredFeed, len(candidates))
+		types = make(map[string]struct{})
+	)
+
+	getFetchPool().run(ctx, candidates, func(ctx context.Context, candidate string) {
+		page, err := fetchPage(ctx, candidate)
+		if err != nil {
+			return
+		}
+
+		parsed, err := feedParser.Parse(bytes.NewReader(page.body))
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		found[positions[candidate]] = &models.DiscoveredFeed{
+			URL:   candidate,
+			Title: utils.StripHTMLAndNormalizeFeedText(parsed.Title),
+			Type:  parsed.FeedType,
+		}
+
+		types[parsed.FeedType] = struct{}{}
+		if len(types) == wellKnownFeedTypes {
+			cancel()
+		}
+	})
+
+	var feeds []models.DiscoveredFeed
+
+	seen := make(map[string]struct{})
+
+	for _, feed := range found {
+		if feed == nil {
+			continue
+		}
+
+		if _, ok := seen[feed.Type]; ok {
+			continue
+		}
+
+		seen[feed.Type] = struct{}{}
+		feeds = append(feeds, *feed)
+	}
+
+	return feeds
+}
@@ -0,0 +1,198 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+const discoveryHomepage = `<!DOCTYPE html>
+<html>
+<head>
+<title>Blog</title>
+<link rel="alternate" type="application/rss+xml" title="Posts" href="/posts.rss">
+<link rel="alternate" type="application/atom+xml" title="Comments" href="https://other.example.com/comments.atom">
+<link rel="alternate" type="application/feed+json" href="feed.json">
+<link rel="alternate" type="application/json" href="/wp-json/wp/v2/pages/1">
+<link rel="stylesheet" href="/style.css">
+<link rel="alternate" type="application/rss+xml" title="Posts again" href="/posts.rss">
+</head>
+<body><link rel="alternate" type="application/rss+xml" href="/in-body.rss"></body>
+</html>`
+
+func TestProbeFeedURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(discoveryHomepage))
+	})
+	mux.HandleFunc("/blog/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><title>No links</title></head><body>Hello</body></html>`))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, _ *http.Request) {
+		// feeds served as text/html are still recognized
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Well known</title></feed>`))
+	})
+	mux.HandleFunc("/posts.rss", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testRSS))
+	})
+	// the same rss feed under two well-known paths is listed once
+	for _, path := range []string{"/feed", "/rss.xml"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(testRSS))
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("linked feeds", func(t *testing.T) {
+		discovery, err := ProbeFeedURL(context.Background(), srv.URL+"/")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []models.DiscoveredFeed{
+			{URL: srv.URL + "/posts.rss", Title: "Posts", Type: "application/rss+xml"},
+			{URL: "https://other.example.com/comments.atom", Title: "Comments", Type: "application/atom+xml"},
+			{URL: srv.URL + "/feed.json", Type: "application/feed+json"},
+		}
+
+		if discovery.IsFeed || len(discovery.Feeds) != len(want) {
+			t.Fatalf("unexpected discovery: %+v", discovery)
+		}
+
+		for i := range want {
+			if discovery.Feeds[i] != want[i] {
+				t.Fatalf("feed %d: expected %+v, got %+v", i, want[i], discovery.Feeds[i])
+			}
+		}
+	})
+
+	t.Run("well-known paths", func(t *testing.T) {
+		discovery, err := ProbeFeedURL(context.Background(), srv.URL+"/blog/")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []models.DiscoveredFeed{
+			{URL: srv.URL + "/feed", Title: "Test feed", Type: "rss"},
+			{URL: srv.URL + "/atom.xml", Title: "Well known", Type: "atom"},
+		}
+
+		if discovery.IsFeed || !slices.Equal(discovery.Feeds, want) {
+			t.Fatalf("expected %+v, got %+v", want, discovery)
+		}
+	})
+
+	t.Run("feed url", func(t *testing.T) {
+		probe, err := ProbeFeedURL(context.Background(), srv.URL+"/posts.rss")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !probe.IsFeed || len(probe.Feeds) != 0 || probe.Title != "Test feed" {
+			t.Fatalf("expected the url to be a feed, got %+v", probe)
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		if _, err := ProbeFeedURL(context.Background(), "ftp://example.com/feed"); err == nil {
+			t.Fatal("expected error for unsupported scheme")
+		}
+	})
+}
+
+func TestProbeFeedURL_SingleFetch(t *testing.T) {
+	setupTestDB(t)
+
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path == "/notes.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("just some notes, definitely not a feed"))
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	t.Run("non-feeds are rejected", func(t *testing.T) {
+		if _, err := ProbeFeedURL(context.Background(), srv.URL+"/notes.txt"); !errors.Is(err, ErrNotFeed) {
+			t.Fatalf("expected ErrNotFeed, got %v", err)
+		}
+	})
+
+	requests = 0
+
+	probe, err := ProbeFeedURL(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	SaveProbedFeed(context.Background(), probe)
+
+	if requests != 1 {
+		t.Fatalf("expected a single download, got %d", requests)
+	}
+
+	if n := countItems(t, srv.URL); n != 1 {
+		t.Fatalf("expected probed feed items to be saved, got %d", n)
+	}
+
+	etag, _, err := db.GetFeedValidators(srv.URL)
+	if err != nil || etag != `"v1"` {
+		t.Fatalf("expected validators of the probed feed to be saved, got %q, %v", etag, err)
+	}
+}
+
+func TestProbeFeedURL_LargeFeed(t *testing.T) {
+	items := strings.Repeat(`<item><title>Post</title><description>`+strings.Repeat("x", 1024)+`</description></item>`, 6<<10)
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel><title>Large</title>` + items + `</channel></rss>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	probe, err := ProbeFeedURL(context.Background(), srv.URL)
+	if err != nil || !probe.IsFeed || len(probe.result.feed.Items) != 6<<10 {
+		t.Fatalf("expected the whole feed to be parsed, got %v", err)
+	}
+
+	defaultClient := httpclient.Client
+	t.Cleanup(func() { httpclient.Client = defaultClient })
+
+	client, err := httpclient.New(httpclient.Config{MaxResponseSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	httpclient.Client = client
+
+	if _, err := ProbeFeedURL(context.Background(), srv.URL); !errors.Is(err, httpclient.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
@@ -1,65 +1,312 @@
 package feeder
 
 import (
-	"log"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
 	"github.com/mmcdole/gofeed"
 )
 
-var feedParser = gofeed.NewParser()
+var feedParser = newFeedParser()
 
-func FetchAndSaveFeeds(urls []string) {
-	for _, url := range urls {
-		slog.Info("[FEEDER]", "fetching feed", url)
+// errNotModified - publisher confirmed that the feed has no changes since the last fetch.
+var errNotModified = errors.New("feed not modified")
 
-		source := ExtractSourceFromURL(url)
-		fetchAndSaveFeed(url, source)
-	}
+const defaultFetchTimeout = 30 * time.Second
+
+// FetchAndSaveFeeds fetches the urls concurrently within the shared fetch pool limits
+// and returns when all of them are done or ctx is cancelled.
+func FetchAndSaveFeeds(ctx context.Context, urls []string) {
+	getFetchPool().run(ctx, urls, func(ctx context.Context, url string) {
+		fetchAndSaveFeed(ctx, url)
+	})
+}
+
+// feedRefresh - outcome of a single feed refresh, err is set for failed fetches
+type feedRefresh struct {
+	notModified bool
+	newItems    int
+	err         error
 }
 
-func fetchAndSaveFeed(url, source string) {
-	fp, err := feedParser.ParseURL(url)
+// fetchAndSaveFeed downloads the feed, saves its items and records the outcome in the feed state.
+func fetchAndSaveFeed(ctx context.Context, url string) feedRefresh {
+	slog.Info("[FEEDER]", "fetching feed", url)
+
+	result, err := fetchFeed(ctx, url)
+	fetchedAt := time.Now()
+
+	// every attempt moves the feed schedule forward, failed ones included
+	if errors.Is(err, errNotModified) {
+		slog.Info("[FEEDER] feed not modified since last fetch", "url", url)
+
+		if setErr := db.SetFeedFetchSucceeded(url, fetchedAt, result.statusCode, -1); setErr != nil {
+			slog.Error("failed to save feed fetch result", "url", url, "error", setErr)
+		}
+
+		fetchFullArticles(ctx, url)
+
+		return feedRefresh{notModified: true}
+	}
+
 	if err != nil {
-		log.Println("Error parsing feed:", err)
+		slog.Error("[FEEDER] failed to fetch feed", "url", url, "status", result.statusCode, "error", err)
 
-		return
+		retryAfter := retryAfterTime(result, fetchedAt)
+
+		setErr := db.SetFeedFetchFailed(url, fetchedAt, result.statusCode, err.Error(), retryAfter, utils.FeedDeadAfterFailures)
+		if setErr != nil {
+			slog.Error("failed to save feed fetch error", "url", url, "error", setErr)
+		}
+
+		return feedRefresh{err: err}
 	}
 
+	return feedRefresh{newItems: saveFetchedFeed(ctx, url, result, fetchedAt)}
+}
+
+// saveFetchedFeed stores items of the downloaded feed, records the successful fetch
+// and returns how many items are new.
+func saveFetchedFeed(ctx context.Context, url string, result fetchResult, fetchedAt time.Time) int {
+	fp := result.feed
+
+	defer func() {
+		if setErr := db.SetFeedFetchSucceeded(url, fetchedAt, result.statusCode, len(fp.Items)); setErr != nil {
+			slog.Error("failed to save feed fetch result", "url", url, "error", setErr)
+		}
+	}()
+
+	if err := db.SetFeedHints(url, feedHints(fp, result.header)); err != nil {
+		slog.Error("failed to save feed refresh hints", "url", url, "error", err)
+	}
+
+	source := utils.StripHTMLAndNormalizeFeedText(fp.Title)
+	newItems := 0
+
 	for _, item := range fp.Items {
-		var exists bool
+		incoming := models.IncomingFeedItem{
+			FeedURL:     url,
+			GUID:        itemGUID(item),
+			Title:       utils.StripHTMLAndNormalizeFeedText(item.Title),
+			Link:        item.Link,
+			Date:        itemDate(item, fetchedAt).Format(time.RFC3339),
+			Source:      source,
+			Description: utils.StripHTMLAndNormalizeFeedText(item.Description),
+			Content:     itemContent(item),
+			FirstSeenAt: fetchedAt,
+		}
 
-		err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM feeds WHERE link = $1 AND feed_url = $2)`, item.Link, url).Scan(&exists)
+		saved, err := db.SaveFeedItem(incoming)
 		if err != nil {
-			slog.Error("Error checking not new item in feed", "error", err)
+			slog.Error("Error saving item in feed:", "url", url, "error", err)
+		}
+
+		if saved == db.ItemInserted {
+			newItems++
+		}
+	}
+
+	fetchFullArticles(ctx, url)
+
+	return newItems
+}
+
+// itemContent returns raw html of the item, full content:encoded if present, description otherwise.
+func itemContent(item *gofeed.Item) string {
+	if content := strings.TrimSpace(item.Content); content != "" {
+		return content
+	}
+
+	return strings.TrimSpace(item.Description)
+}
+
+// itemDate resolves the item date: published, then updated, then the time it was first seen.
+// The date is in UTC, so stored dates share one format whatever offset the publisher used.
+func itemDate(item *gofeed.Item, firstSeen time.Time) time.Time {
+	switch {
+	case item.PublishedParsed != nil:
+		return item.PublishedParsed.UTC()
+	case item.UpdatedParsed != nil:
+		return item.UpdatedParsed.UTC()
+	default:
+		return firstSeen.UTC()
+	}
+}
+
+// itemGUID returns the publisher GUID of the item. Items without one are identified by their link
+// with tracking parameters removed, so edited titles and changed utm tags don't make a new item,
+// and by the hash of their title and publish date when there is no link either.
+func itemGUID(item *gofeed.Item) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+
+	if link := canonicalItemLink(item.Link); link != "" {
+		return "link:" + link
+	}
+
+	sum := sha256.Sum256([]byte(item.Title + "\n" + item.Published))
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// trackingParams - query parameters added by mailing and ad tools that don't change the linked page,
+// utm_* ones are matched by prefix.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// canonicalItemLink normalizes the item link for identity: lower case scheme and host, no fragment,
+// no tracking parameters and the remaining ones sorted. Links that don't parse are only trimmed.
+func canonicalItemLink(link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
 
-			continue
+	query := u.Query()
+	for name := range query {
+		if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
 		}
+	}
+
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// fetchResult - what a single feed download brought back
+type fetchResult struct {
+	feed       *gofeed.Feed
+	statusCode int
+	header     http.Header
+}
 
-		if !exists {
-			date := item.PublishedParsed.Format(time.RFC3339)
+// fetchFeed downloads and parses the feed sending stored ETag and Last-Modified validators,
+// so unchanged feeds cost a single 304 response answered with errNotModified.
+// Status code and headers are filled in whenever the publisher responded, errors included.
+func fetchFeed(ctx context.Context, url string) (fetchResult, error) {
+	var result fetchResult
+
+	etag, lastModified, err := db.GetFeedValidators(url)
+	if err != nil {
+		slog.Error("failed to get feed validators, fetching unconditionally", "url", url, "error", err)
+	}
+
+	timeout := utils.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
-			insertQuery := `INSERT INTO feeds (title, link, date, source, description, feed_url) VALUES (?, ?, ?, ?, ?, ?)`
-			title := utils.StripHTMLAndNormalizeFeedText(item.Title)
-			description := utils.StripHTMLAndNormalizeFeedText(item.Description)
-			normalizedSource := utils.StripHTMLAndNormalizeFeedText(source)
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
 
-			_, err := db.DB.Exec(insertQuery, title, item.Link, date, normalizedSource, description, url)
-			if err != nil {
-				slog.Error("Error inserting new item in feed:", "error", err)
-			}
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Error("failed to close feed response body", "url", url, "error", closeErr)
 		}
+	}()
+
+	result.statusCode = resp.StatusCode
+	result.header = resp.Header
+
+	if resp.StatusCode == http.StatusNotModified {
+		return result, errNotModified
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	fp, err := feedParser.Parse(resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse feed %s: %w", url, err)
 	}
+
+	err = db.SetFeedValidators(url, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+	if err != nil {
+		slog.Error("failed to save feed validators", "url", url, "error", err)
+	}
+
+	result.feed = fp
+
+	return result, nil
 }
 
-func ExtractSourceFromURL(url string) string {
-	host := ""
-	if parsedURL, err := feedParser.ParseURL(url); err == nil && parsedURL.Title != "" {
-		host = parsedURL.Title
+// retryAfterTime returns when the publisher asked to come back with 429 or 503 response, zero if it didn't.
+// Retry-After is either a number of seconds or an HTTP date.
+func retryAfterTime(result fetchResult, now time.Time) time.Time {
+	if result.statusCode != http.StatusTooManyRequests && result.statusCode != http.StatusServiceUnavailable {
+		return time.Time{}
+	}
+
+	value := strings.TrimSpace(result.header.Get("Retry-After"))
+	if value == "" {
+		return time.Time{}
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return time.Time{}
+		}
+
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date
 	}
 
-	return host
+	return time.Time{}
 }
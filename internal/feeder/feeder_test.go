@@ -0,0 +1,340 @@
+package feeder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+<title>Test feed</title>
+<link>https://example.com</link>
+<item>
+<title>First post</title>
+<link>https://example.com/1</link>
+<description>Hello &amp; welcome</description>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+</item>
+</channel>
+</rss>`
+
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in‑memory sqlite: %v", err)
+	}
+
+	conn.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to close test db: %v", err)
+		}
+	})
+
+	db.DB = conn
+
+	if err := db.RunMigrations(db.MigrateUp, 0); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+}
+
+func countItems(t *testing.T, feedURL string) int {
+	t.Helper()
+
+	var n int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM feeds WHERE feed_url = ?`, feedURL).Scan(&n); err != nil {
+		t.Fatalf("failed to count items: %v", err)
+	}
+
+	return n
+}
+
+func TestFetchAndSaveFeed_ConditionalRequests(t *testing.T) {
+	setupTestDB(t)
+
+	var full, notModified int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` &&
+			r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	if full != 1 || notModified != 1 {
+		t.Fatalf("expected one full and one conditional fetch, got full=%d not_modified=%d", full, notModified)
+	}
+
+	if n := countItems(t, srv.URL); n != 1 {
+		t.Fatalf("expected 1 stored item, got %d", n)
+	}
+
+	var source, description string
+	if err := db.DB.QueryRow(`SELECT source, description FROM feeds WHERE feed_url = ?`, srv.URL).Scan(&source, &description); err != nil {
+		t.Fatalf("failed to read stored item: %v", err)
+	}
+
+	if source != "Test feed" || description != "Hello & welcome" {
+		t.Fatalf("unexpected stored item: source=%q description=%q", source, description)
+	}
+}
+
+func TestFetchFeed_HTTPError(t *testing.T) {
+	setupTestDB(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	result, err := fetchFeed(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatalf("expected error for 404 response, got nil")
+	}
+
+	if result.statusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", result.statusCode)
+	}
+
+	etag, lastModified, err := db.GetFeedValidators(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if etag != "" || lastModified != "" {
+		t.Fatalf("validators must not be stored for failed fetch, got %q %q", etag, lastModified)
+	}
+}
+
+func TestFetchAndSaveFeed_Health(t *testing.T) {
+	setupTestDB(t)
+
+	failing := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	user := addTestUser(t, "carol", 60, srv.URL)
+
+	healthOf := func() models.FeedHealth {
+		t.Helper()
+
+		feeds, err := db.GetUserFeeds(user)
+		if err != nil || len(feeds) != 1 {
+			t.Fatalf("failed to get user feeds: %v %v", feeds, err)
+		}
+
+		return feeds[0].Health
+	}
+
+	if status := healthOf().Status(); status != models.FeedStatusPending {
+		t.Fatalf("expected pending feed before first fetch, got %s", status)
+	}
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	health := healthOf()
+	if health.Status() != models.FeedStatusFailing || health.ConsecutiveFailures != 2 ||
+		health.LastStatus != http.StatusServiceUnavailable || health.LastError == "" {
+		t.Fatalf("unexpected health after failures: %+v", health)
+	}
+
+	failing = false
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	health = healthOf()
+	if health.Status() != models.FeedStatusOK || health.ConsecutiveFailures != 0 ||
+		health.LastStatus != http.StatusOK || health.LastError != "" || health.ItemCount != 1 ||
+		health.LastSuccessAt.IsZero() {
+		t.Fatalf("unexpected health after recovery: %+v", health)
+	}
+}
+
+func TestFetchAndSaveFeed_MissingPublishDates(t *testing.T) {
+	setupTestDB(t)
+
+	const atom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>Atom feed</title>
+<entry>
+<id>urn:1</id>
+<title>Updated only</title>
+<link href="https://example.com/a"/>
+<updated>2024-03-01T12:00:00+02:00</updated>
+</entry>
+<entry>
+<id>urn:2</id>
+<title>No dates at all</title>
+<link href="https://example.com/b"/>
+</entry>
+</feed>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(atom))
+	}))
+	defer srv.Close()
+
+	before := time.Now().Add(-time.Second)
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	dates := make(map[string]string)
+
+	rows, err := db.DB.Query(`SELECT guid, date FROM feeds WHERE feed_url = ?`, srv.URL)
+	if err != nil {
+		t.Fatalf("failed to read stored items: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guid, date string
+		if err := rows.Scan(&guid, &date); err != nil {
+			t.Fatalf("failed to scan stored item: %v", err)
+		}
+
+		dates[guid] = date
+	}
+
+	if dates["urn:1"] != "2024-03-01T10:00:00Z" {
+		t.Fatalf("expected updated date in UTC for item without published, got %q", dates["urn:1"])
+	}
+
+	firstSeen, err := time.Parse(time.RFC3339, dates["urn:2"])
+	if err != nil || firstSeen.Before(before.Truncate(time.Second)) || !strings.HasSuffix(dates["urn:2"], "Z") {
+		t.Fatalf("expected first seen date for item without dates, got %q", dates["urn:2"])
+	}
+}
+
+func TestFetchAndSaveFeed_KeepsRawContent(t *testing.T) {
+	setupTestDB(t)
+
+	const rss = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+<title>Content feed</title>
+<item>
+<guid>full</guid>
+<title>Full content</title>
+<description>&lt;p&gt;Short &lt;b&gt;summary&lt;/b&gt;&lt;/p&gt;</description>
+<content:encoded><![CDATA[<p>Full <a href="/more">article</a></p>]]></content:encoded>
+</item>
+<item>
+<guid>summary</guid>
+<title>Description only</title>
+<description>&lt;p&gt;Only &lt;i&gt;description&lt;/i&gt;&lt;/p&gt;</description>
+</item>
+</channel>
+</rss>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(rss))
+	}))
+	defer srv.Close()
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	tests := map[string]struct{ description, content string }{
+		"full":    {description: "Short summary", content: `<p>Full <a href="/more">article</a></p>`},
+		"summary": {description: "Only description", content: "<p>Only <i>description</i></p>"},
+	}
+
+	for guid, want := range tests {
+		var description, content string
+
+		err := db.DB.QueryRow(`SELECT description, content FROM feeds WHERE feed_url = ? AND guid = ?`, srv.URL, guid).
+			Scan(&description, &content)
+		if err != nil {
+			t.Fatalf("failed to read stored item %s: %v", guid, err)
+		}
+
+		if description != want.description || content != want.content {
+			t.Fatalf("item %s: expected %q and %q, got %q and %q", guid, want.description, want.content, description, content)
+		}
+	}
+}
+
+func TestFetchAndSaveFeed_ItemsWithoutGUID(t *testing.T) {
+	setupTestDB(t)
+
+	const rss = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+<title>No guids</title>
+<item>
+<title>%s</title>
+<link>https://Example.com/post?id=1&amp;%s</link>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+</item>
+<item>
+<title>Linkless</title>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+</item>
+</channel>
+</rss>`
+
+	title, tracking := "First title", "utm_source=rss&utm_medium=feed"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, rss, title, tracking)
+	}))
+	defer srv.Close()
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	title, tracking = "Edited title", "utm_source=newsletter&fbclid=abc"
+
+	fetchAndSaveFeed(context.Background(), srv.URL)
+
+	if n := countItems(t, srv.URL); n != 2 {
+		t.Fatalf("expected 2 stored items, got %d", n)
+	}
+
+	var guid, storedTitle string
+
+	err := db.DB.QueryRow(`SELECT guid, title FROM feeds WHERE feed_url = ? AND title != 'Linkless'`, srv.URL).
+		Scan(&guid, &storedTitle)
+	if err != nil {
+		t.Fatalf("failed to read stored item: %v", err)
+	}
+
+	if guid != "link:https://example.com/post?id=1" || storedTitle != "Edited title" {
+		t.Fatalf("expected item updated in place, got guid %q and title %q", guid, storedTitle)
+	}
+}
@@ -0,0 +1,125 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	readability "github.com/go-shiori/go-readability"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// fullArticlesPerFetch - max number of item pages downloaded after a single feed fetch
+	fullArticlesPerFetch = 10
+	// maxArticlePageSize - item pages are cut to that size before extraction
+	maxArticlePageSize = 5 << 20
+)
+
+var errNoArticle = errors.New("no readable article found")
+
+// fetchFullArticles downloads pages of the newest feed items without extracted article,
+// if any subscriber of the feed turned full article mode on. Every item is tried once.
+func fetchFullArticles(ctx context.Context, feedURL string) {
+	wanted, err := db.FeedWantsFullArticle(feedURL)
+	if err != nil {
+		slog.Error("failed to check full article mode", "url", feedURL, "error", err)
+
+		return
+	}
+
+	if !wanted {
+		return
+	}
+
+	items, err := db.GetItemsWithoutFullContent(feedURL, fullArticlesPerFetch)
+	if err != nil {
+		slog.Error("failed to get items without full content", "url", feedURL, "error", err)
+
+		return
+	}
+
+	for _, item := range items {
+		content, err := extractArticle(ctx, item.Link)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Warn("[FEEDER] failed to extract full article", "url", item.Link, "error", err)
+		}
+
+		if err := db.SetItemFullContent(item.ID, content, time.Now()); err != nil {
+			slog.Error("failed to save full article", "url", item.Link, "error", err)
+		}
+	}
+}
+
+// extractArticle downloads the page and returns html of its main content found by the readability algorithm.
+func extractArticle(ctx context.Context, link string) (string, error) {
+	pageURL, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid article url: %w", err)
+	}
+
+	timeout := utils.FetchTimeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", link, err)
+	}
+
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", link, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Error("failed to close article response body", "url", link, "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxArticlePageSize), contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", link, err)
+	}
+
+	article, err := readability.FromReader(body, pageURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract article from %s: %w", link, err)
+	}
+
+	if strings.TrimSpace(article.TextContent) == "" {
+		return "", errNoArticle
+	}
+
+	return article.Content, nil
+}
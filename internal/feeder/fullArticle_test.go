@@ -0,0 +1,143 @@
+package feeder
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+)
+
+// newArticleServer serves the teaser feed and the article page from testdata,
+// every other page is not found. Returns the server and requests count per path.
+func newArticleServer(t *testing.T) (*httptest.Server, map[string]int) {
+	t.Helper()
+
+	feed, err := os.ReadFile("testdata/teaser.xml")
+	if err != nil {
+		t.Fatalf("failed to read feed fixture: %v", err)
+	}
+
+	article, err := os.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatalf("failed to read article fixture: %v", err)
+	}
+
+	requests := make(map[string]int)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+
+		switch r.URL.Path {
+		case "/feed.xml":
+			_, _ = w.Write([]byte(strings.ReplaceAll(string(feed), "{{BASE}}", srv.URL)))
+		case "/posts/small-servers":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(article)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func itemFullContent(t *testing.T, feedURL, guid string) (sql.NullString, sql.NullInt64) {
+	t.Helper()
+
+	var (
+		content   sql.NullString
+		fetchedAt sql.NullInt64
+	)
+
+	err := db.DB.QueryRow(`SELECT full_content, full_content_fetched_at FROM feeds WHERE feed_url = ? AND guid = ?`,
+		feedURL, guid).Scan(&content, &fetchedAt)
+	if err != nil {
+		t.Fatalf("failed to read item %s: %v", guid, err)
+	}
+
+	return content, fetchedAt
+}
+
+func TestFetchAndSaveFeed_FullArticle(t *testing.T) {
+	setupTestDB(t)
+
+	srv, requests := newArticleServer(t)
+	feedURL := srv.URL + "/feed.xml"
+
+	userID := addTestUser(t, "alice", 60, feedURL)
+
+	t.Run("pages are not downloaded by default", func(t *testing.T) {
+		fetchAndSaveFeed(context.Background(), feedURL)
+
+		if requests["/posts/small-servers"] != 0 {
+			t.Fatal("expected no article requests without full article mode")
+		}
+	})
+
+	feeds, err := db.GetUserFeeds(userID)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("failed to get user feeds: %v", err)
+	}
+
+	if err := db.SetUserFeedFullArticle(userID, strconv.Itoa(feeds[0].ID), true); err != nil {
+		t.Fatalf("failed to turn full article mode on: %v", err)
+	}
+
+	t.Run("article is extracted", func(t *testing.T) {
+		fetchAndSaveFeed(context.Background(), feedURL)
+
+		content, fetchedAt := itemFullContent(t, feedURL, "article")
+		if !fetchedAt.Valid {
+			t.Fatal("expected article fetch to be recorded")
+		}
+
+		for _, want := range []string{"Teaser-only feeds are the remaining annoyance", srv.URL + "/guide", "rack.jpg"} {
+			if !strings.Contains(content.String, want) {
+				t.Errorf("expected %q in extracted article %q", want, content.String)
+			}
+		}
+
+		for _, unwanted := range []string{"Popular post one", "Copyright footer", "analytics", "Subscribe"} {
+			if strings.Contains(content.String, unwanted) {
+				t.Errorf("unexpected %q in extracted article", unwanted)
+			}
+		}
+
+		content, fetchedAt = itemFullContent(t, feedURL, "missing")
+		if !fetchedAt.Valid || content.String != "" {
+			t.Fatalf("expected failed attempt to be recorded without content, got %q", content.String)
+		}
+	})
+
+	t.Run("every page is downloaded once", func(t *testing.T) {
+		fetchAndSaveFeed(context.Background(), feedURL)
+
+		if requests["/posts/small-servers"] != 1 || requests["/posts/removed"] != 1 {
+			t.Fatalf("expected a single request per page, got %v", requests)
+		}
+	})
+
+	t.Run("article view prefers the extracted content", func(t *testing.T) {
+		var itemID int
+		if err := db.DB.QueryRow(`SELECT id FROM feeds WHERE guid = 'article'`).Scan(&itemID); err != nil {
+			t.Fatalf("failed to get item id: %v", err)
+		}
+
+		item, err := db.GetUserFeedItem(userID, itemID)
+		if err != nil {
+			t.Fatalf("failed to get item: %v", err)
+		}
+
+		if !strings.Contains(item.Content, "Teaser-only feeds") {
+			t.Fatalf("expected extracted article as item content, got %q", item.Content)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package feeder
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+// keys of gofeed.Feed.Custom holding RSS channel fields the default translator drops
+const (
+	customTTL       = "ttl"
+	customSkipHours = "skipHours"
+	customSkipDays  = "skipDays"
+)
+
+// hintsRSSTranslator - default RSS translator keeping ttl, skipHours and skipDays of the channel in Feed.Custom
+type hintsRSSTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t *hintsRSSTranslator) Translate(feed any) (*gofeed.Feed, error) {
+	result, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := feed.(*rss.Feed)
+
+	result.Custom = map[string]string{
+		customTTL:       strings.TrimSpace(channel.TTL),
+		customSkipHours: strings.Join(channel.SkipHours, ","),
+		customSkipDays:  strings.Join(channel.SkipDays, ","),
+	}
+
+	return result, nil
+}
+
+func newFeedParser() *gofeed.Parser {
+	parser := gofeed.NewParser()
+	parser.RSSTranslator = &hintsRSSTranslator{}
+
+	return parser
+}
+
+// syndicationPeriods - sy:updatePeriod values, the period is divided by sy:updateFrequency
+var syndicationPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// feedHints collects refresh hints of the downloaded feed: RSS ttl, skipHours and skipDays,
+// sy:updatePeriod with sy:updateFrequency and Cache-Control max-age of the response.
+// The longest of the intervals wins, malformed values are ignored.
+func feedHints(fp *gofeed.Feed, header http.Header) models.FeedHints {
+	var (
+		hints    models.FeedHints
+		interval time.Duration
+	)
+
+	if ttl, err := strconv.Atoi(fp.Custom[customTTL]); err == nil && ttl > 0 {
+		interval = max(interval, time.Duration(ttl)*time.Minute)
+	}
+
+	interval = max(interval, syndicationInterval(fp), cacheMaxAge(header))
+
+	hints.MinIntervalMinutes = int(math.Ceil(min(interval, models.MaxFeedHintInterval).Minutes()))
+
+	for _, raw := range strings.Split(fp.Custom[customSkipHours], ",") {
+		// RSS uses 0-23, some publishers write 24 for midnight
+		if hour, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil && hour >= 0 && hour <= 24 {
+			hints.SkipHours = append(hints.SkipHours, hour%24)
+		}
+	}
+
+	for _, raw := range strings.Split(fp.Custom[customSkipDays], ",") {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(raw))]; ok {
+			hints.SkipDays = append(hints.SkipDays, day)
+		}
+	}
+
+	return hints
+}
+
+func syndicationInterval(fp *gofeed.Feed) time.Duration {
+	sy, ok := fp.Extensions["sy"]
+	if !ok {
+		return 0
+	}
+
+	period, ok := syndicationPeriods[strings.ToLower(strings.TrimSpace(extensionValue(sy, "updatePeriod")))]
+	if !ok {
+		return 0
+	}
+
+	frequency, err := strconv.Atoi(strings.TrimSpace(extensionValue(sy, "updateFrequency")))
+	if err != nil || frequency <= 0 {
+		frequency = 1
+	}
+
+	return period / time.Duration(frequency)
+}
+
+func extensionValue(extensions map[string][]ext.Extension, name string) string {
+	if values := extensions[name]; len(values) > 0 {
+		return values[0].Value
+	}
+
+	return ""
+}
+
+// cacheMaxAge returns max-age of the response Cache-Control header, zero if caching is forbidden.
+func cacheMaxAge(header http.Header) time.Duration {
+	var maxAge time.Duration
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return maxAge
+}
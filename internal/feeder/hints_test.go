@@ -0,0 +1,158 @@
+package feeder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+const hintedRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+<channel>
+<title>Hinted feed</title>
+<link>https://example.com</link>
+<ttl>90</ttl>
+<sy:updatePeriod>daily</sy:updatePeriod>
+<sy:updateFrequency>12</sy:updateFrequency>
+<skipHours><hour>1</hour><hour>2</hour><hour>24</hour></skipHours>
+<skipDays><day>Sunday</day></skipDays>
+<item>
+<title>First post</title>
+<link>https://example.com/1</link>
+</item>
+</channel>
+</rss>`
+
+func TestFeedHints(t *testing.T) {
+	tests := []struct {
+		name         string
+		feed         string
+		cacheControl string
+		wantMinutes  int
+	}{
+		{name: "syndication period wins", feed: hintedRSS, wantMinutes: 120},
+		{name: "max-age wins", feed: hintedRSS, cacheControl: "public, max-age=10800", wantMinutes: 180},
+		{name: "no-cache ignores max-age", feed: hintedRSS, cacheControl: "no-cache, max-age=10800", wantMinutes: 120},
+		{name: "ttl only", feed: strings.Replace(hintedRSS, "daily", "sometimes", 1), wantMinutes: 90},
+		{name: "capped", feed: hintedRSS, cacheControl: "max-age=31536000", wantMinutes: 24 * 60},
+		{name: "no hints", feed: testRSS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp, err := feedParser.ParseString(tt.feed)
+			if err != nil {
+				t.Fatalf("failed to parse feed: %v", err)
+			}
+
+			hints := feedHints(fp, http.Header{"Cache-Control": []string{tt.cacheControl}})
+
+			if hints.MinIntervalMinutes != tt.wantMinutes {
+				t.Fatalf("expected %d minutes, got %d", tt.wantMinutes, hints.MinIntervalMinutes)
+			}
+
+			if tt.feed == hintedRSS &&
+				(!slices.Equal(hints.SkipHours, []int{1, 2, 0}) || !slices.Equal(hints.SkipDays, []time.Weekday{time.Sunday})) {
+				t.Fatalf("unexpected skips: %+v", hints)
+			}
+		})
+	}
+}
+
+func TestFeedSchedule_Hints(t *testing.T) {
+	// Saturday
+	last := time.Date(2025, 1, 4, 20, 30, 0, 0, time.UTC)
+
+	schedule := models.FeedSchedule{IntervalMinutes: 15, LastFetchAt: last, Hints: models.FeedHints{MinIntervalMinutes: 60}}
+
+	if next := schedule.NextFetchAt(); !next.Equal(last.Add(time.Hour)) {
+		t.Fatalf("expected interval stretched to the publisher hint, got %v", next)
+	}
+
+	schedule.Hints.SkipHours = []int{21, 22}
+	if next := schedule.NextFetchAt(); !next.Equal(time.Date(2025, 1, 4, 23, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected skipped hours to be skipped, got %v", next)
+	}
+
+	schedule.Hints.SkipHours = []int{23}
+	schedule.Hints.SkipDays = []time.Weekday{time.Sunday}
+	schedule.LastFetchAt = last.Add(2 * time.Hour)
+
+	if next := schedule.NextFetchAt(); !next.Equal(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected skipped days to be skipped, got %v", next)
+	}
+
+	for hour := range 24 {
+		schedule.Hints.SkipHours = append(schedule.Hints.SkipHours, hour)
+	}
+
+	if next := schedule.NextFetchAt(); !next.Equal(schedule.LastFetchAt.Add(time.Hour)) {
+		t.Fatalf("expected hints skipping everything to be ignored, got %v", next)
+	}
+}
+
+func TestRefreshDueFeeds_SubscriptionInterval(t *testing.T) {
+	setupTestDB(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=7200")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	hourly, weekly, manual := srv.URL+"/hourly", srv.URL+"/weekly", srv.URL+"/manual"
+
+	userID := addTestUser(t, "dave", 60, hourly, weekly, manual)
+
+	feeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		t.Fatalf("failed to get feeds: %v", err)
+	}
+
+	week, off := 7*24*60, 0
+
+	for _, feed := range feeds {
+		switch feed.FeedURL {
+		case weekly:
+			err = db.SetUserFeedInterval(userID, strconv.Itoa(feed.ID), &week)
+		case manual:
+			err = db.SetUserFeedInterval(userID, strconv.Itoa(feed.ID), &off)
+		}
+
+		if err != nil {
+			t.Fatalf("failed to set feed interval: %v", err)
+		}
+	}
+
+	refreshDueFeeds()
+
+	schedules, err := db.GetFeedSchedules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	intervals := make(map[string]models.FeedSchedule)
+	for _, schedule := range schedules {
+		intervals[schedule.FeedURL] = schedule
+	}
+
+	if _, ok := intervals[manual]; ok || len(intervals) != 2 {
+		t.Fatalf("expected subscription with autorefresh disabled to be skipped, got %+v", schedules)
+	}
+
+	if intervals[weekly].IntervalMinutes != week || intervals[hourly].IntervalMinutes != 60 {
+		t.Fatalf("expected subscription interval to override the user's one, got %+v", schedules)
+	}
+
+	hinted := intervals[hourly]
+	if hinted.Hints.MinIntervalMinutes != 120 || !hinted.NextFetchAt().Equal(hinted.LastFetchAt.Add(2*time.Hour)) {
+		t.Fatalf("expected Cache-Control max-age to postpone the next fetch, got %+v", hinted)
+	}
+}
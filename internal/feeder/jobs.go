@@ -0,0 +1,136 @@
+package feeder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+const (
+	// jobWorkers - how many jobs run at the same time, so a large import doesn't hold up other users' refreshes
+	jobWorkers = 2
+	// jobTimeout - upper bound for a single job, feeds not fetched by then are reported failed
+	jobTimeout      = 30 * time.Minute
+	jobPollInterval = 10 * time.Second
+	// jobHistory - finished jobs older than that are removed
+	jobHistory = 7 * 24 * time.Hour
+)
+
+// jobQueued wakes an idle worker up when a job is enqueued, workers poll the queue anyway.
+var jobQueued = make(chan struct{}, 1)
+
+// EnqueueJob queues a job of the user fetching feedURLs in the background and returns its id.
+func EnqueueJob(userID int, kind string, feedURLs []string) (int64, error) {
+	jobID, err := db.CreateJob(userID, kind, feedURLs, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case jobQueued <- struct{}{}:
+	default:
+	}
+
+	return jobID, nil
+}
+
+// StartJobWorkers resumes jobs interrupted by a restart and then runs queued jobs as they come
+func StartJobWorkers() {
+	if err := db.RequeueInterruptedJobs(); err != nil {
+		slog.Error("failed to requeue interrupted jobs", "error", err)
+	}
+
+	for range jobWorkers {
+		go runJobWorker()
+	}
+}
+
+func runJobWorker() {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for runNextJob(context.Background()) {
+		}
+
+		select {
+		case <-jobQueued:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNextJob runs the oldest queued job, returns false if the queue is empty.
+func runNextJob(ctx context.Context) bool {
+	job, ok, err := db.ClaimNextJob(time.Now())
+	if err != nil {
+		slog.Error("failed to get next job", "error", err)
+
+		return false
+	}
+
+	if !ok {
+		return false
+	}
+
+	runJob(ctx, job)
+
+	return true
+}
+
+// runJob fetches every feed of the job within the shared fetch pool limits, recording progress as feeds finish.
+func runJob(ctx context.Context, job models.Job) {
+	slog.Info("[JOBS] running job", "id", job.ID, "kind", job.Kind, "user", job.UserID, "feeds", len(job.Feeds))
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	urls := make([]string, 0, len(job.Feeds))
+	for _, feed := range job.Feeds {
+		urls = append(urls, feed.FeedURL)
+	}
+
+	getFetchPool().run(ctx, urls, func(ctx context.Context, url string) {
+		if err := db.SetJobFeedRunning(job.ID, url); err != nil {
+			slog.Error("failed to save job progress", "id", job.ID, "url", url, "error", err)
+		}
+
+		refresh := fetchAndSaveFeed(ctx, url)
+
+		status, fetchErr := models.JobUpdated, ""
+
+		switch {
+		case refresh.err != nil:
+			status, fetchErr = models.JobFailed, refresh.err.Error()
+		case refresh.notModified:
+			status = models.JobNotModified
+		}
+
+		if err := db.SetJobFeedFinished(job.ID, url, status, refresh.newItems, fetchErr, time.Now()); err != nil {
+			slog.Error("failed to save job progress", "id", job.ID, "url", url, "error", err)
+		}
+	})
+
+	if err := db.FinishJob(job.ID, time.Now()); err != nil {
+		slog.Error("failed to finish job", "id", job.ID, "error", err)
+	}
+
+	slog.Info("[JOBS] job finished", "id", job.ID)
+}
+
+// pruneJobs removes finished jobs older than jobHistory
+func pruneJobs(now time.Time) {
+	removed, err := db.DeleteJobsFinishedBefore(now.Add(-jobHistory))
+	if err != nil {
+		slog.Error("failed to remove finished jobs", "error", err)
+
+		return
+	}
+
+	if removed > 0 {
+		slog.Info("[JOBS] removed finished jobs", "removed", removed)
+	}
+}
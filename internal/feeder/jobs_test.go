@@ -0,0 +1,90 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func TestRunNextJob(t *testing.T) {
+	setupTestDB(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	okURL, brokenURL := srv.URL+"/feed", srv.URL+"/broken"
+	userID := addTestUser(t, "jobs", 0, okURL, brokenURL)
+
+	jobID, err := EnqueueJob(userID, models.JobRefresh, []string{okURL, brokenURL})
+	if err != nil {
+		t.Fatalf("failed to enqueue job: %v", err)
+	}
+
+	if active, err := db.GetUserActiveJobID(userID, models.JobRefresh); err != nil || active != jobID {
+		t.Fatalf("expected job %d to be active, got %d, %v", jobID, active, err)
+	}
+
+	// a job left running by a stopped process is picked up again
+	if _, ok, err := db.ClaimNextJob(time.Now()); err != nil || !ok {
+		t.Fatalf("failed to claim job: %v", err)
+	}
+
+	if err := db.RequeueInterruptedJobs(); err != nil {
+		t.Fatalf("failed to requeue jobs: %v", err)
+	}
+
+	if !runNextJob(context.Background()) {
+		t.Fatal("expected the queued job to run")
+	}
+
+	if runNextJob(context.Background()) {
+		t.Fatal("expected the queue to be empty")
+	}
+
+	job, err := db.GetUserJob(userID, jobID)
+	if err != nil {
+		t.Fatalf("failed to get job: %v", err)
+	}
+
+	if !job.Finished() || job.Progress() != 2 || job.Failed() != 1 {
+		t.Fatalf("expected finished job with one failed feed, got %+v", job)
+	}
+
+	updated, failed := job.Feeds[0], job.Feeds[1]
+
+	if updated.Status != models.JobUpdated || updated.NewItems != 1 {
+		t.Fatalf("expected the feed to be updated with a new item, got %+v", updated)
+	}
+
+	if failed.Status != models.JobFailed || failed.Error == "" {
+		t.Fatalf("expected the broken feed to fail with an error, got %+v", failed)
+	}
+
+	if active, err := db.GetUserActiveJobID(userID, models.JobRefresh); err != nil || active != 0 {
+		t.Fatalf("expected no active jobs, got %d, %v", active, err)
+	}
+
+	if _, err := db.GetUserJob(userID+1, jobID); !errors.Is(err, db.ErrJobNotFound) {
+		t.Fatalf("expected jobs of other users to be hidden, got %v", err)
+	}
+
+	pruneJobs(time.Now().Add(jobHistory + time.Minute))
+
+	if _, err := db.GetUserJob(userID, jobID); !errors.Is(err, db.ErrJobNotFound) {
+		t.Fatalf("expected old finished job to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package feeder
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+)
+
+const (
+	defaultFetchConcurrency     = 8
+	defaultFetchHostConcurrency = 2
+)
+
+// fetchPool bounds how many feeds are downloaded at once, both in total and per publisher host.
+// Limits are shared by every caller, so a manual refresh and the autorefresh cycle
+// running at the same time still respect them together.
+type fetchPool struct {
+	slots       chan struct{}
+	perHost     int
+	hostSlotsMu sync.Mutex
+	hostSlots   map[string]chan struct{}
+}
+
+var (
+	defaultPool     *fetchPool
+	defaultPoolOnce sync.Once
+)
+
+// getFetchPool returns the shared pool configured from utils on the first use.
+func getFetchPool() *fetchPool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = newFetchPool(utils.FetchConcurrency, utils.FetchHostConcurrency)
+	})
+
+	return defaultPool
+}
+
+func newFetchPool(concurrency, perHost int) *fetchPool {
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+
+	if perHost <= 0 {
+		perHost = defaultFetchHostConcurrency
+	}
+
+	return &fetchPool{
+		slots:     make(chan struct{}, concurrency),
+		perHost:   perHost,
+		hostSlots: make(map[string]chan struct{}),
+	}
+}
+
+// run calls fetch for every url and waits for all of them. Urls not started before ctx is done are skipped.
+func (p *fetchPool) run(ctx context.Context, urls []string, fetch func(ctx context.Context, url string)) {
+	var wg sync.WaitGroup
+
+	for _, u := range urls {
+		wg.Add(1)
+
+		go func(feedURL string) {
+			defer wg.Done()
+
+			release, ok := p.acquire(ctx, hostOf(feedURL))
+			if !ok {
+				return
+			}
+			defer release()
+
+			fetch(ctx, feedURL)
+		}(u)
+	}
+
+	wg.Wait()
+}
+
+// acquire takes a slot of the host and then a global slot, returns false if ctx is done while waiting.
+// Host slot goes first, so urls queued behind a busy host don't hold global slots.
+func (p *fetchPool) acquire(ctx context.Context, host string) (func(), bool) {
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	hostSlots := p.hostSlotsFor(host)
+
+	select {
+	case hostSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		<-hostSlots
+
+		return nil, false
+	}
+
+	return func() {
+		<-p.slots
+		<-hostSlots
+	}, true
+}
+
+func (p *fetchPool) hostSlotsFor(host string) chan struct{} {
+	p.hostSlotsMu.Lock()
+	defer p.hostSlotsMu.Unlock()
+
+	slots, ok := p.hostSlots[host]
+	if !ok {
+		slots = make(chan struct{}, p.perHost)
+		p.hostSlots[host] = slots
+	}
+
+	return slots
+}
+
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+
+	return strings.ToLower(parsed.Hostname())
+}
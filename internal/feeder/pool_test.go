@@ -0,0 +1,86 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchPool_RespectsLimits(t *testing.T) {
+	pool := newFetchPool(3, 1)
+
+	var (
+		mu         sync.Mutex
+		perHost    = make(map[string]int)
+		maxPerHost int
+		active     int32
+		maxActive  int32
+		calls      int32
+	)
+
+	urls := make([]string, 0, 12)
+	for i := 0; i < 12; i++ {
+		urls = append(urls, fmt.Sprintf("https://host%d.example.com/feed/%d", i%4, i))
+	}
+
+	pool.run(context.Background(), urls, func(_ context.Context, url string) {
+		atomic.AddInt32(&calls, 1)
+
+		current := atomic.AddInt32(&active, 1)
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+				break
+			}
+		}
+
+		host := hostOf(url)
+
+		mu.Lock()
+		perHost[host]++
+		if perHost[host] > maxPerHost {
+			maxPerHost = perHost[host]
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		perHost[host]--
+		mu.Unlock()
+
+		atomic.AddInt32(&active, -1)
+	})
+
+	if calls != int32(len(urls)) {
+		t.Fatalf("expected %d fetches, got %d", len(urls), calls)
+	}
+
+	if maxActive > 3 {
+		t.Fatalf("global concurrency exceeded: %d", maxActive)
+	}
+
+	if maxPerHost > 1 {
+		t.Fatalf("per host concurrency exceeded: %d", maxPerHost)
+	}
+}
+
+func TestFetchPool_Cancelled(t *testing.T) {
+	pool := newFetchPool(1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+
+	pool.run(ctx, []string{"https://a.example.com/1", "https://b.example.com/2"}, func(context.Context, string) {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	if calls != 0 {
+		t.Fatalf("expected no fetches after cancellation, got %d", calls)
+	}
+}
@@ -0,0 +1,58 @@
+package feeder
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+)
+
+const defaultPruneInterval = 6 * time.Hour
+
+// StartPruning runs retention policies right away and then periodically, next to StartAutoRefresh.
+// Old finished jobs are removed on the same schedule.
+func StartPruning() {
+	interval := utils.PruneInterval
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := PruneFeedItems(); err != nil {
+			slog.Error("failed to prune feed items", "error", err)
+		}
+
+		pruneJobs(time.Now())
+
+		<-ticker.C
+	}
+}
+
+// GlobalRetentionPolicy returns the retention policy configured for all feeds
+func GlobalRetentionPolicy() models.RetentionPolicy {
+	return models.RetentionPolicy{
+		MaxAgeDays: utils.RetentionMaxAgeDays,
+		MaxItems:   utils.RetentionMaxItemsPerFeed,
+	}
+}
+
+// PruneFeedItems deletes feed items exceeding the global and per-feed retention policies
+func PruneFeedItems() ([]models.PruneResult, error) {
+	results, err := db.PruneFeedItems(GlobalRetentionPolicy(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Removed > 0 {
+			slog.Info("[PRUNE] removed feed items", "policy", result.Policy, "feed", result.FeedURL, "removed", result.Removed)
+		}
+	}
+
+	return results, nil
+}
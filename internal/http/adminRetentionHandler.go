@@ -0,0 +1,128 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const adminRetentionTemplate = "templates/admin_retention"
+
+func adminRetentionRender(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user id from ctx: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	overrides, err := db.GetFeedRetentions()
+	if err != nil {
+		log.Error("failed to get feed retentions: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	stats, err := db.GetPruneStats()
+	if err != nil {
+		log.Error("failed to get prune stats: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	feedURLs, err := db.GetSubscribedFeedURLs()
+	if err != nil {
+		log.Error("failed to get subscribed feeds: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	return c.Render(adminRetentionTemplate, fiber.Map{
+		"Global":     feeder.GlobalRetentionPolicy(),
+		"Overrides":  overrides,
+		"PruneStats": stats,
+		"FeedURLs":   feedURLs,
+		"User":       userInfo,
+		"Title":      "RapidFeed - Retention",
+	})
+}
+
+func setFeedRetentionHandler(c *fiber.Ctx) error {
+	feedURL := strings.TrimSpace(c.FormValue("feed_url"))
+	if feedURL == "" {
+		log.Warn("empty feed url for retention is passed, nothing to set")
+
+		return c.Redirect("/admin/retention", http.StatusFound)
+	}
+
+	maxAgeDays, err := optionalLimit(c.FormValue("max_age_days"))
+	if err != nil {
+		log.Errorf("invalid max age for feed %s retention: %v", feedURL, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	maxItems, err := optionalLimit(c.FormValue("max_items"))
+	if err != nil {
+		log.Errorf("invalid max items for feed %s retention: %v", feedURL, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	if err := db.SetFeedRetention(feedURL, maxAgeDays, maxItems); err != nil {
+		log.Errorf("failed to set feed %s retention: %v", feedURL, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	return c.Redirect("/admin/retention", http.StatusFound)
+}
+
+func deleteFeedRetentionHandler(c *fiber.Ctx) error {
+	feedURL := c.FormValue("feed_url")
+	if feedURL == "" {
+		log.Warn("empty feed url for retention is passed, nothing to delete")
+
+		return c.Redirect("/admin/retention", http.StatusFound)
+	}
+
+	if err := db.DeleteFeedRetention(feedURL); err != nil {
+		log.Errorf("failed to delete feed %s retention: %v", feedURL, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	return c.Redirect("/admin/retention", http.StatusFound)
+}
+
+func pruneNowHandler(c *fiber.Ctx) error {
+	if _, err := feeder.PruneFeedItems(); err != nil {
+		log.Error("failed to prune feed items: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(err))
+	}
+
+	return c.Redirect("/admin/retention", http.StatusFound)
+}
+
+// optionalLimit parses an optional non-negative form value, empty value means "use the default",
+// e.g. the global retention policy or the user's refresh interval
+func optionalLimit(raw string) (*int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("limit must be a non-negative number, got %q", raw)
+	}
+
+	return &n, nil
+}
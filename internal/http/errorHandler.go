@@ -16,6 +16,16 @@ func defaultForbiddenMap() models.Error {
 	}
 }
 
+func defaultNotFoundMap() models.Error {
+	return models.Error{
+		Status:  "404",
+		Title:   "Not Found",
+		Error:   nil,
+		Message: "Requested page doesn't exist or is not available to you.",
+		User:    nil,
+	}
+}
+
 func defaultInternalErrorMap(err error) models.Error {
 	return models.Error{
 		Status:  "500",
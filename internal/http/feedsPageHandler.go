@@ -11,6 +11,7 @@ import (
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -38,30 +39,46 @@ func feedsPageHandler(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	pageStr := c.Query("page")
-	perPageStr := c.Query("per_page")
+	page, perPage = paginationFromQuery(c)
 
-	page, err = strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
+	selectedTag := strings.TrimSpace(c.Query("tag"))
+	selectedSource := strings.TrimSpace(c.Query("source"))
+	hideRead := c.Query("hide_read") == "1"
+	searchQuery := strings.TrimSpace(c.Query("q"))
 
-	perPage, err = strconv.Atoi(perPageStr)
-	if err != nil || perPage < 1 {
-		perPage = 100
+	unreadCounts, err := db.GetUserUnreadCounts(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s unread counts: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	selectedTag := strings.TrimSpace(c.Query("tag"))
-	selectedSource := strings.TrimSpace(c.Query("source"))
+	for i := range userFeeds {
+		userFeeds[i].UnreadCount = unreadCounts[userFeeds[i].FeedURL]
+	}
 
 	availableTags := collectTags(userFeeds)
 	filteredFeeds := filterFeeds(userFeeds, selectedTag, selectedSource)
 	filteredFeedUrls := extractFeedUrls(filteredFeeds)
 
+	sortBy, err := db.GetUserTimelineSort(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s timeline sort: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	itemsFilter := models.FeedItemsFilter{
+		FeedURLs: filteredFeedUrls,
+		HideRead: hideRead,
+		Query:    searchQuery,
+		SortBy:   sortBy,
+	}
+
 	if len(filteredFeedUrls) > 0 {
 		offset := (page - 1) * perPage
 
-		totalCount, err = db.GetTotalUserFeedItemsCount(filteredFeedUrls)
+		totalCount, err = db.GetTotalUserFeedItemsCount(userInfo.ID, itemsFilter)
 		if err != nil {
 			log.Errorf("failed to count %s total feed items: %v", userInfo.Username, err)
 
@@ -70,7 +87,7 @@ func feedsPageHandler(c *fiber.Ctx) error {
 
 		totalPages = int(math.Ceil(float64(totalCount) / float64(perPage)))
 
-		items, err = db.GetUserFeedItems(userInfo.ID, filteredFeedUrls, perPage, offset)
+		items, err = db.GetUserFeedItems(userInfo.ID, itemsFilter, perPage, offset)
 		if err != nil {
 			log.Errorf("failed to get %s feed items: %v", userInfo.Username, err)
 
@@ -117,16 +134,35 @@ func feedsPageHandler(c *fiber.Ctx) error {
 		"PaginatedItems": paginatedItems,
 		"UserFeeds":      userFeeds,
 		"Tags":           availableTags,
+		"TagUnread":      countUnreadByTag(userFeeds, availableTags),
 		"Filters": fiber.Map{
-			"Tag":    selectedTag,
-			"Source": selectedSource,
+			"Tag":      selectedTag,
+			"Source":   selectedSource,
+			"HideRead": hideRead,
+			"Query":    searchQuery,
 		},
-		"User":    userInfo,
-		"Title":   "RapidFeed",
-		"NoFeeds": len(userFeeds) == 0,
+		"CurrentURL": c.OriginalURL(),
+		"User":       userInfo,
+		"Title":      "RapidFeed",
+		"NoFeeds":    len(userFeeds) == 0,
 	})
 }
 
+// paginationFromQuery - returns page and per page values from query with defaults fallback.
+func paginationFromQuery(c *fiber.Ctx) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 100
+	}
+
+	return page, perPage
+}
+
 func extractFeedUrls(feeds []models.UserFeed) []string {
 	urls := make([]string, 0, len(feeds))
 	for _, feed := range feeds {
@@ -159,7 +195,7 @@ func filterFeeds(feeds []models.UserFeed, tag, source string) []models.UserFeed
 func collectTags(feeds []models.UserFeed) []string {
 	unique := make(map[string]string)
 	for _, feed := range feeds {
-		for _, tag := range parseTags(feed.Tags) {
+		for _, tag := range utils.SplitTags(feed.Tags) {
 			key := strings.ToLower(tag)
 			if _, ok := unique[key]; !ok {
 				unique[key] = tag
@@ -177,13 +213,26 @@ func collectTags(feeds []models.UserFeed) []string {
 	return tags
 }
 
+func countUnreadByTag(feeds []models.UserFeed, tags []string) map[string]int {
+	counts := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		for _, feed := range feeds {
+			if feedHasTag(feed, tag) {
+				counts[tag] += feed.UnreadCount
+			}
+		}
+	}
+
+	return counts
+}
+
 func feedHasTag(feed models.UserFeed, target string) bool {
 	target = strings.ToLower(strings.TrimSpace(target))
 	if target == "" {
 		return false
 	}
 
-	for _, tag := range parseTags(feed.Tags) {
+	for _, tag := range utils.SplitTags(feed.Tags) {
 		if strings.ToLower(tag) == target {
 			return true
 		}
@@ -191,16 +240,3 @@ func feedHasTag(feed models.UserFeed, target string) bool {
 
 	return false
 }
-
-func parseTags(tags string) []string {
-	parts := strings.Split(tags, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		tag := strings.TrimSpace(part)
-		if tag != "" {
-			result = append(result, tag)
-		}
-	}
-
-	return result
-}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/httpclient"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/imageproxy"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// proxiedImageMaxAge - how long browsers may keep proxied images, they never change for the same url
+const proxiedImageMaxAge = "private, max-age=604800"
+
+// imageProxy - nil when the proxy is disabled, images are linked directly then
+var imageProxy *imageproxy.Proxy
+
+func initImageProxy() {
+	if !utils.ImageProxyEnabled {
+		return
+	}
+
+	proxy, err := imageproxy.New(imageproxy.Config{
+		CacheDir:      utils.ImageCacheDir,
+		CacheMaxBytes: int64(utils.ImageCacheMaxMB) << 20,
+		MaxWidth:      utils.ImageProxyMaxWidth,
+		Key:           utils.SecretKey,
+		Client:        httpclient.Client,
+		Timeout:       utils.FetchTimeout,
+	})
+	if err != nil {
+		log.Error("failed to initialize image proxy, images will be linked directly: ", err)
+
+		return
+	}
+
+	imageProxy = proxy
+}
+
+// proxiedImageURL returns the image proxy rewriter for rendered content, nil if the proxy is disabled
+func proxiedImageURL() func(string) string {
+	if imageProxy == nil {
+		return nil
+	}
+
+	return imageProxy.URL
+}
+
+func imageProxyHandler(c *fiber.Ctx) error {
+	raw := c.Query("u")
+
+	if imageProxy == nil || !imageProxy.Verify(raw, c.Query("s")) {
+		return c.SendStatus(http.StatusForbidden)
+	}
+
+	data, err := imageProxy.Image(c.UserContext(), raw)
+	if err != nil {
+		log.Warnf("failed to proxy image %s: %v", raw, err)
+
+		if errors.Is(err, imageproxy.ErrNotImage) || errors.Is(err, imageproxy.ErrTooLarge) {
+			return c.SendStatus(http.StatusUnsupportedMediaType)
+		}
+
+		return c.SendStatus(http.StatusBadGateway)
+	}
+
+	c.Set(fiber.HeaderCacheControl, proxiedImageMaxAge)
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+
+	return c.Send(data)
+}
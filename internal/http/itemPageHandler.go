@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/sanitizer"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const itemTemplate = "templates/item"
+
+// itemPageHandler - renders a single item with its sanitized content, opening it marks the item as read
+func itemPageHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user id from ctx: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).Render(errorTemplate, defaultNotFoundMap())
+	}
+
+	item, err := db.GetUserFeedItem(userInfo.ID, itemID)
+	if err != nil {
+		if errors.Is(err, db.ErrItemNotFound) {
+			return c.Status(http.StatusNotFound).Render(errorTemplate, defaultNotFoundMap())
+		}
+
+		log.Errorf("failed to get item %d for %s: %v", itemID, userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	if !item.IsRead {
+		if err := db.SetItemsReadState(userInfo.ID, []int{item.ID}, true); err != nil {
+			log.Errorf("failed to mark item %d read for %s: %v", item.ID, userInfo.Username, err)
+		} else {
+			item.IsRead = true
+		}
+	}
+
+	return c.Render(itemTemplate, fiber.Map{
+		"Item":       item,
+		"Content":    template.HTML(sanitizer.Sanitize(item.Content, item.Link, proxiedImageURL())),
+		"BackURL":    safeRedirectPath(c.Query("back")),
+		"CurrentURL": c.OriginalURL(),
+		"User":       userInfo,
+		"Title":      "RapidFeed - " + item.Title,
+	})
+}
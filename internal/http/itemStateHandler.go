@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// markItemsHandler - marks one or several items (e.g. the whole page) as read or unread.
+func markItemsHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	itemIDs := make([]int, 0)
+
+	for _, raw := range c.Request().PostArgs().PeekMulti("item_id") {
+		id, err := strconv.Atoi(string(raw))
+		if err != nil {
+			log.Warnf("skipping invalid item id %q from %s", raw, userInfo.Username)
+
+			continue
+		}
+
+		itemIDs = append(itemIDs, id)
+	}
+
+	read := c.FormValue("state") != "unread"
+
+	if err := db.SetItemsReadState(userInfo.ID, itemIDs, read); err != nil {
+		log.Errorf("failed to set read state for %s: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	return c.Redirect(safeRedirectPath(c.FormValue("redirect")), http.StatusFound)
+}
+
+// starItemHandler - stars or unstars a single item.
+func starItemHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	itemID, err := strconv.Atoi(c.FormValue("item_id"))
+	if err != nil {
+		log.Errorf("failed to parse item id for star, username %s, err %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	starred := c.FormValue("state") != "unstar"
+
+	if err := db.SetItemStarred(userInfo.ID, itemID, starred); err != nil {
+		log.Errorf("failed to set starred state for %s: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	return c.Redirect(safeRedirectPath(c.FormValue("redirect")), http.StatusFound)
+}
+
+// markOlderItemsHandler - marks all items older than the selected amount of days as read,
+// respecting the tag and source filters the user is currently looking at.
+func markOlderItemsHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	days, err := strconv.Atoi(c.FormValue("older_than_days"))
+	if err != nil || days < 0 {
+		log.Errorf("failed to parse older than days, username %s, err %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	userFeeds, err := db.GetUserFeeds(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s feeds: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	filteredFeeds := filterFeeds(userFeeds, c.FormValue("tag"), c.FormValue("source"))
+	before := time.Now().AddDate(0, 0, -days)
+
+	marked, err := db.MarkItemsReadBefore(userInfo.ID, extractFeedUrls(filteredFeeds), before)
+	if err != nil {
+		log.Errorf("failed to mark %s items as read: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	log.Infof("marked %d items as read for %s", marked, userInfo.Username)
+
+	return c.Redirect(safeRedirectPath(c.FormValue("redirect")), http.StatusFound)
+}
+
+// safeRedirectPath - allows redirects only to local paths, falls back to index page.
+func safeRedirectPath(raw string) string {
+	if !strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, "//") || strings.HasPrefix(raw, "/\\") {
+		return "/"
+	}
+
+	return raw
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/opml"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// maxOPMLFileSize - uploaded OPML files larger than that are rejected
+const maxOPMLFileSize = 2 << 20
+
+// opmlImportReport - outcome of an OPML import shown on the settings page
+type opmlImportReport struct {
+	Error      string
+	Added      []opml.Entry
+	Duplicates []opml.Entry
+	Invalid    []opmlInvalidEntry
+	// JobPath - progress page of the background download of the added feeds
+	JobPath string
+}
+
+type opmlInvalidEntry struct {
+	Entry  opml.Entry
+	Reason string
+}
+
+func exportOPMLHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	feeds, err := db.GetUserFeeds(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s feeds for export: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	out, err := opml.Export(fmt.Sprintf("RapidFeed subscriptions of %s", userInfo.Username), feeds, time.Now())
+	if err != nil {
+		log.Errorf("failed to export %s feeds: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	c.Set(fiber.HeaderContentType, "text/x-opml; charset=utf-8")
+	c.Attachment("rapidfeed.opml")
+
+	return c.Send(out)
+}
+
+func importOPMLHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	report := opmlImportReport{}
+
+	entries, err := readOPMLUpload(c)
+	if err != nil {
+		log.Warnf("failed to read %s opml upload: %v", userInfo.Username, err)
+
+		report.Error = err.Error()
+
+		return renderUserSettings(c, fiber.Map{"OPMLImport": report})
+	}
+
+	feeds, err := db.GetUserFeeds(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s feeds: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	existing := make(map[string]struct{}, len(feeds))
+	for _, feed := range feeds {
+		existing[feed.FeedURL] = struct{}{}
+	}
+
+	added := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if err := opml.ValidateFeedURL(entry.URL); err != nil {
+			report.Invalid = append(report.Invalid, opmlInvalidEntry{Entry: entry, Reason: err.Error()})
+
+			continue
+		}
+
+		if _, ok := existing[entry.URL]; ok {
+			report.Duplicates = append(report.Duplicates, entry)
+
+			continue
+		}
+
+		err := db.AddUserFeed(userInfo.ID, entry.Title, entry.URL, utils.NormalizeTags(strings.Join(entry.Tags, ",")))
+		if err != nil {
+			log.Errorf("failed to import %s to %s feeds: %v", entry.URL, userInfo.Username, err)
+
+			report.Invalid = append(report.Invalid, opmlInvalidEntry{Entry: entry, Reason: "failed to save subscription"})
+
+			continue
+		}
+
+		existing[entry.URL] = struct{}{}
+		added = append(added, entry.URL)
+		report.Added = append(report.Added, entry)
+	}
+
+	if len(added) > 0 {
+		jobID, err := feeder.EnqueueJob(userInfo.ID, models.JobImport, added)
+		if err != nil {
+			log.Errorf("failed to queue download of %s imported feeds: %v", userInfo.Username, err)
+		} else {
+			report.JobPath = jobPath(jobID)
+		}
+	}
+
+	return renderUserSettings(c, fiber.Map{"OPMLImport": report})
+}
+
+func readOPMLUpload(c *fiber.Ctx) ([]opml.Entry, error) {
+	header, err := c.FormFile("opml_file")
+	if err != nil {
+		return nil, errors.New("no OPML file uploaded")
+	}
+
+	if header.Size > maxOPMLFileSize {
+		return nil, fmt.Errorf("OPML file is too large, max size is %d KB", maxOPMLFileSize>>10)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.New("failed to open uploaded file")
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Error("failed to close uploaded opml file: ", closeErr)
+		}
+	}()
+
+	entries, err := opml.Parse(file)
+	if err != nil {
+		return nil, errors.New("file is not a valid OPML document")
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("no subscriptions found in the OPML file")
+	}
+
+	return entries, nil
+}
@@ -1,14 +1,29 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
 
+// manualRefreshTimeout - upper bound for a user-triggered fetch done while the browser waits
+const manualRefreshTimeout = 2 * time.Minute
+
+const jobTemplate = "templates/job"
+
+// jobReloadSeconds - how often the job page reloads itself until the job is done
+const jobReloadSeconds = 3
+
+// refreshHandler - queues a refresh of all live user feeds and shows its progress,
+// a refresh already waiting or running is shown instead of queueing another one
 func refreshHandler(c *fiber.Ctx) error {
 	userInfo, err := getSessionInfo(c)
 	if err != nil {
@@ -17,14 +32,78 @@ func refreshHandler(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	userFeeds, err := db.GetUserFeedUrls(userInfo.ID)
+	jobID, err := db.GetUserActiveJobID(userInfo.ID, models.JobRefresh)
 	if err != nil {
-		log.Error("failed to get user feed urls: ", err)
+		log.Error("failed to get active refresh job: ", err)
 
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	feeder.FetchAndSaveFeeds(userFeeds)
+	if jobID == 0 {
+		userFeeds, err := db.GetUserLiveFeedUrls(userInfo.ID)
+		if err != nil {
+			log.Error("failed to get user feed urls: ", err)
+
+			return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+		}
+
+		jobID, err = feeder.EnqueueJob(userInfo.ID, models.JobRefresh, userFeeds)
+		if err != nil {
+			log.Errorf("failed to queue %s feeds refresh: %v", userInfo.Username, err)
+
+			return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+		}
+	}
+
+	return c.Redirect(jobPath(jobID), http.StatusFound)
+}
+
+func jobPath(jobID int64) string {
+	return fmt.Sprintf("/jobs/%d", jobID)
+}
+
+// jobPageHandler - shows progress of the user job, the page reloads itself until the job is done
+func jobPageHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user id from ctx: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	jobID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusNotFound).Render(errorTemplate, defaultNotFoundMap())
+	}
+
+	job, err := db.GetUserJob(userInfo.ID, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrJobNotFound) {
+			return c.Status(http.StatusNotFound).Render(errorTemplate, defaultNotFoundMap())
+		}
+
+		log.Errorf("failed to get job %d for %s: %v", jobID, userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	data := fiber.Map{
+		"Job":   job,
+		"User":  userInfo,
+		"Title": "RapidFeed - " + jobTitle(job),
+	}
+
+	if !job.Finished() {
+		data["ReloadSeconds"] = jobReloadSeconds
+	}
+
+	return c.Render(jobTemplate, data)
+}
+
+func jobTitle(job models.Job) string {
+	if job.Kind == models.JobImport {
+		return "Importing feeds"
+	}
 
-	return c.Redirect("/", http.StatusFound)
+	return "Refreshing feeds"
 }
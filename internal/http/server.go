@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/imageproxy"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/ui"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
 	"github.com/gofiber/fiber/v2"
@@ -13,6 +14,8 @@ import (
 func New() {
 	sessionStore = newSessionStore()
 
+	initImageProxy()
+
 	app := fiber.New(fiber.Config{
 		Views: initTemplateEngine(),
 	})
@@ -35,8 +38,13 @@ func New() {
 	// protected app routes with check session middleware
 	appRoutes := app.Group("/", checkSessionMiddleware())
 	appRoutes.Get("/", feedsPageHandler)
+	appRoutes.Get("/starred", starredPageHandler)
+	appRoutes.Get("/item/:id", itemPageHandler)
+	appRoutes.Get(imageproxy.Path, imageProxyHandler)
 	appRoutes.Get("/refresh", refreshHandler)
+	appRoutes.Get("/jobs/:id", jobPageHandler)
 	appRoutes.Get("/settings", userSettingsRender)
+	appRoutes.Get("/settings/opml", exportOPMLHandler)
 	appRoutes.Get("/logout", logoutHandler)
 
 	internalApiRoutes := app.Group("/internal/api/", checkSessionMiddleware())
@@ -44,12 +52,19 @@ func New() {
 	internalApiRoutes.Post("/user/settings/feed/add", addFeedHandler)
 	internalApiRoutes.Post("/user/settings/feed/update", updateFeedHandler)
 	internalApiRoutes.Post("/user/settings/feed/remove", removeFeedHandler)
+	internalApiRoutes.Post("/user/settings/feed/revive", reviveFeedHandler)
+	internalApiRoutes.Post("/user/settings/opml/import", importOPMLHandler)
 	internalApiRoutes.Post("/user/settings/autorefresh/set", autorefreshIntervalChangeHadler)
+	internalApiRoutes.Post("/user/settings/timeline/set", timelineSortChangeHandler)
 	internalApiRoutes.Post("/user/settings/apiToken/add", addUserTokenHandler)
 	internalApiRoutes.Post("/user/settings/apiToken/revoke", revokeUserTokenHandler)
+	internalApiRoutes.Post("/user/items/read", markItemsHandler)
+	internalApiRoutes.Post("/user/items/read/older", markOlderItemsHandler)
+	internalApiRoutes.Post("/user/items/star", starItemHandler)
 
 	adminRoutes := app.Group("/admin/", adminSessionMiddleware())
 	adminRoutes.Get("/users", adminSettingsRender)
+	adminRoutes.Get("/retention", adminRetentionRender)
 
 	adminApiRoutes := app.Group("/internal/api/admin/", adminSessionMiddleware())
 	adminApiRoutes.Post("/user/add", addUserHandler)
@@ -57,6 +72,9 @@ func New() {
 	adminApiRoutes.Post("/user/unblock", unblockUserHandler)
 	adminApiRoutes.Post("/user/role/change", changeUserRoleHandler)
 	adminApiRoutes.Post("/user/feed/remove", removeUserFeedHandler)
+	adminApiRoutes.Post("/retention/feed/set", setFeedRetentionHandler)
+	adminApiRoutes.Post("/retention/feed/delete", deleteFeedRetentionHandler)
+	adminApiRoutes.Post("/retention/prune", pruneNowHandler)
 
 	log.Fatal(app.Listen(utils.Listen))
 }
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"math"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const starredTemplate = "templates/starred"
+
+func starredPageHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user id from ctx: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	page, perPage := paginationFromQuery(c)
+
+	sortBy, err := db.GetUserTimelineSort(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s timeline sort: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	filter := models.FeedItemsFilter{StarredOnly: true, SortBy: sortBy}
+
+	totalCount, err := db.GetTotalUserFeedItemsCount(userInfo.ID, filter)
+	if err != nil {
+		log.Errorf("failed to count %s starred items: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	items, err := db.GetUserFeedItems(userInfo.ID, filter, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Errorf("failed to get %s starred items: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	return c.Render(starredTemplate, fiber.Map{
+		"PaginatedItems": models.PaginatedFeedItems{
+			Items:      items,
+			Page:       page,
+			PerPage:    perPage,
+			TotalPages: int(math.Ceil(float64(totalCount) / float64(perPage))),
+			TotalItems: totalCount,
+		},
+		"CurrentURL": c.OriginalURL(),
+		"User":       userInfo,
+		"Title":      "RapidFeed - Starred",
+	})
+}
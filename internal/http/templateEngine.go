@@ -1,9 +1,13 @@
 package http
 
 import (
+	"html/template"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/ui"
 	"github.com/gofiber/template/html/v2"
 )
@@ -21,6 +25,14 @@ func tmplFuncMap() map[string]any {
 		"sub":      func(a, b int) int { return a - b },
 		"add":      func(a, b int) int { return a + b },
 		"urlquery": func(raw string) string { return url.QueryEscape(raw) },
+		"datetime": func(t time.Time) string {
+			if t.IsZero() {
+				return "never"
+			}
+
+			return t.Local().Format(time.DateTime)
+		},
+		"highlight": highlightHTML,
 		"seq": func(start, end int) []int {
 			if start > end {
 				start, end = end, start
@@ -48,3 +60,11 @@ func tmplFuncMap() map[string]any {
 		},
 	}
 }
+
+// highlightReplacer - escaped search highlight markers become <mark> tags
+var highlightReplacer = strings.NewReplacer(models.HighlightStart, "<mark>", models.HighlightEnd, "</mark>")
+
+// highlightHTML escapes text of a search result and marks matched terms.
+func highlightHTML(text string) template.HTML {
+	return template.HTML(highlightReplacer.Replace(template.HTMLEscapeString(text)))
+}
@@ -1,8 +1,10 @@
 package http
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,13 +13,24 @@ import (
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/auth"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
 	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
 
-const userSettingsTemplate = "templates/user_settings"
+const (
+	userSettingsTemplate  = "templates/user_settings"
+	feedDiscoveryTemplate = "templates/feed_discovery"
+)
 
 func userSettingsRender(c *fiber.Ctx) error {
+	return renderUserSettings(c, nil)
+}
+
+// renderUserSettings - renders settings page, extra values are added to the template data,
+// e.g. results of a form that can't be passed through a redirect
+func renderUserSettings(c *fiber.Ctx, extra fiber.Map) error {
 	userInfo, err := getSessionInfo(c)
 	if err != nil {
 		log.Error("failed to get user id from ctx: ", err)
@@ -50,6 +63,13 @@ func userSettingsRender(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
+	timelineSort, err := db.GetUserTimelineSort(userInfo.ID)
+	if err != nil {
+		log.Errorf("failed to get %s timeline sort: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
 	lastUpdate, err := db.GetLastUpdateTS(userInfo.ID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		log.Errorf("failed to get last update ts for %s feeds: %v", userInfo.Username, err)
@@ -75,17 +95,24 @@ func userSettingsRender(c *fiber.Ctx) error {
 		nuStr = "Will be performed soon"
 	}
 
-	return c.Render(userSettingsTemplate, fiber.Map{
+	data := fiber.Map{
 		"UserFeeds":       userFeeds,
 		"User":            userInfo,
 		"UserToken":       userToken,
 		"Title":           "RapidFeed - Settings",
 		"RefreshInterval": refreshInterval,
+		"TimelineSort":    timelineSort,
 		"LastUpdate":      luStr,
 		"NextUpdate":      nuStr,
 		"PasswordError":   c.Query("password_error"),
 		"PasswordSuccess": c.Query("password_success"),
-	})
+	}
+
+	for key, value := range extra {
+		data[key] = value
+	}
+
+	return c.Render(userSettingsTemplate, data)
 }
 
 func changePasswordHandler(c *fiber.Ctx) error {
@@ -129,9 +156,9 @@ func addFeedHandler(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	feedUrl := c.FormValue("feed_url")
+	feedUrl := strings.TrimSpace(c.FormValue("feed_url"))
 	feedTitle := strings.TrimSpace(c.FormValue("feed_title"))
-	feedTags := normalizeTags(c.FormValue("feed_tags"))
+	feedTags := utils.NormalizeTags(c.FormValue("feed_tags"))
 
 	feeds, err := db.GetUserFeeds(userInfo.ID)
 	if err != nil {
@@ -146,8 +173,22 @@ func addFeedHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	ctx, cancel := context.WithTimeout(c.UserContext(), manualRefreshTimeout)
+	defer cancel()
+
+	probe, err := feeder.ProbeFeedURL(ctx, feedUrl)
+	if err != nil {
+		log.Warnf("failed to add %s to %s feeds: %v", feedUrl, userInfo.Username, err)
+
+		return renderUserSettings(c, fiber.Map{"AddFeedError": addFeedErrorMessage(err)})
+	}
+
+	if !probe.IsFeed {
+		return renderFeedDiscovery(c, feedUrl, probe.Feeds, feeds)
+	}
+
 	if feedTitle == "" {
-		feedTitle = feeder.ExtractSourceFromURL(feedUrl)
+		feedTitle = probe.Title
 	}
 
 	err = db.AddUserFeed(userInfo.ID, feedTitle, feedUrl, feedTags)
@@ -157,39 +198,86 @@ func addFeedHandler(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
-	feedUrls, err := db.GetUserFeedUrls(userInfo.ID)
-	if err != nil {
-		log.Errorf("failed to get new %s feeds list: %v", userInfo.Username, err)
+	if c.FormValue("full_article") != "" {
+		if err := setFullArticleByURL(userInfo.ID, feedUrl); err != nil {
+			log.Errorf("failed to turn on full articles of %s for %s: %v", feedUrl, userInfo.Username, err)
 
-		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+			return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+		}
 	}
 
-	feeder.FetchAndSaveFeeds(feedUrls)
+	feeder.SaveProbedFeed(ctx, probe)
 
 	return c.Redirect("/settings#manage-feeds", http.StatusFound)
 }
 
-func normalizeTags(rawTags string) string {
-	parts := strings.Split(rawTags, ",")
-	seen := make(map[string]struct{})
-	cleaned := make([]string, 0, len(parts))
+// addFeedErrorMessage - explains on the settings page why the url can't be subscribed to
+func addFeedErrorMessage(err error) string {
+	if errors.Is(err, feeder.ErrNotFeed) {
+		return err.Error() + ". Check the address, it should point to a feed or a website page linking one."
+	}
 
-	for _, part := range parts {
-		tag := strings.TrimSpace(part)
-		if tag == "" {
-			continue
-		}
+	return "Failed to download the feed: " + err.Error()
+}
 
-		key := strings.ToLower(tag)
-		if _, ok := seen[key]; ok {
-			continue
-		}
+// discoveredFeedChoice - feed shown on the discovery page, Subscribed ones can't be added again
+type discoveredFeedChoice struct {
+	models.DiscoveredFeed
+	Subscribed bool
+}
+
+// renderFeedDiscovery - lets the user pick one of the feeds found on the website page,
+// subscription form values are carried over to the chosen feed
+func renderFeedDiscovery(c *fiber.Ctx, pageURL string, discovered []models.DiscoveredFeed, subscribed []models.UserFeed) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	if len(discovered) == 0 {
+		return renderUserSettings(c, fiber.Map{
+			"AddFeedError": fmt.Sprintf("%s is a web page without any RSS, Atom or JSON feed.", pageURL),
+		})
+	}
+
+	existing := make(map[string]struct{}, len(subscribed))
+	for _, feed := range subscribed {
+		existing[feed.FeedURL] = struct{}{}
+	}
+
+	choices := make([]discoveredFeedChoice, 0, len(discovered))
+	for _, feed := range discovered {
+		_, ok := existing[feed.URL]
+		choices = append(choices, discoveredFeedChoice{DiscoveredFeed: feed, Subscribed: ok})
+	}
+
+	return c.Render(feedDiscoveryTemplate, fiber.Map{
+		"PageURL":     pageURL,
+		"Feeds":       choices,
+		"FeedTitle":   strings.TrimSpace(c.FormValue("feed_title")),
+		"FeedTags":    utils.NormalizeTags(c.FormValue("feed_tags")),
+		"FullArticle": c.FormValue("full_article") != "",
+		"User":        userInfo,
+		"Title":       "RapidFeed - Choose a feed",
+	})
+}
+
+// setFullArticleByURL - turns full article mode on for the just added subscription
+func setFullArticleByURL(userID int, feedUrl string) error {
+	feeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return err
+	}
 
-		seen[key] = struct{}{}
-		cleaned = append(cleaned, tag)
+	for _, feed := range feeds {
+		if feed.FeedURL == feedUrl {
+			return db.SetUserFeedFullArticle(userID, strconv.Itoa(feed.ID), true)
+		}
 	}
 
-	return strings.Join(cleaned, ", ")
+	return nil
 }
 
 func removeFeedHandler(c *fiber.Ctx) error {
@@ -212,6 +300,24 @@ func removeFeedHandler(c *fiber.Ctx) error {
 	return c.Redirect("/settings#manage-feeds", http.StatusFound)
 }
 
+// reviveFeedHandler - re-enables a feed marked dead after repeated failures, autorefresh fetches it on its next check
+func reviveFeedHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	if _, err := db.ReviveUserFeed(userInfo.ID, c.FormValue("feed_id")); err != nil {
+		log.Errorf("failed to re-enable %s feed: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	return c.Redirect("/settings#manage-feeds", http.StatusFound)
+}
+
 func updateFeedHandler(c *fiber.Ctx) error {
 	userInfo, err := getSessionInfo(c)
 	if err != nil {
@@ -227,7 +333,7 @@ func updateFeedHandler(c *fiber.Ctx) error {
 	}
 
 	feedTitle := strings.TrimSpace(c.FormValue("feed_title"))
-	feedTags := normalizeTags(c.FormValue("feed_tags"))
+	feedTags := utils.NormalizeTags(c.FormValue("feed_tags"))
 
 	if err := db.UpdateUserFeed(userInfo.ID, feedId, feedTitle, feedTags); err != nil {
 		log.Error("failed to update user feed: ", err)
@@ -235,6 +341,25 @@ func updateFeedHandler(c *fiber.Ctx) error {
 		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
 	}
 
+	if err := db.SetUserFeedFullArticle(userInfo.ID, feedId, c.FormValue("full_article") != ""); err != nil {
+		log.Error("failed to update user feed full article mode: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	interval, err := optionalLimit(c.FormValue("refresh_interval"))
+	if err != nil {
+		log.Errorf("failed to parse feed refresh interval, username %s, err %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	if err := db.SetUserFeedInterval(userInfo.ID, feedId, interval); err != nil {
+		log.Error("failed to update user feed refresh interval: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
 	return c.Redirect("/settings#manage-feeds", http.StatusFound)
 }
 
@@ -265,6 +390,24 @@ func autorefreshIntervalChangeHadler(c *fiber.Ctx) error {
 	return c.Redirect("/settings#autorefresh", http.StatusFound)
 }
 
+func timelineSortChangeHandler(c *fiber.Ctx) error {
+	userInfo, err := getSessionInfo(c)
+	if err != nil {
+		log.Error("failed to get user info from session: ", err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	err = db.SetUserTimelineSort(userInfo.ID, c.FormValue("timeline_sort"))
+	if err != nil {
+		log.Errorf("failed to set %s timeline sort: %v", userInfo.Username, err)
+
+		return c.Render(errorTemplate, defaultInternalErrorMap(nil))
+	}
+
+	return c.Redirect("/settings#timeline", http.StatusFound)
+}
+
 func addUserTokenHandler(c *fiber.Ctx) error {
 	userInfo, err := getSessionInfo(c)
 	if err != nil {
@@ -0,0 +1,235 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// ErrResponseTooLarge - response body exceeds the configured max response size
+var ErrResponseTooLarge = errors.New("response is too large")
+
+// Client - shared client of every outgoing request the server makes, configured by Init on startup
+var Client = &http.Client{Timeout: defaultTimeout}
+
+// Config - settings of outgoing requests, zero values keep Go defaults
+type Config struct {
+	// UserAgent is sent with requests which don't set their own
+	UserAgent string
+	Timeout   time.Duration
+	// MaxResponseSize - reading a larger response body fails with ErrResponseTooLarge, 0 for no limit
+	MaxResponseSize int64
+	// ProxyURL - http, https or socks5 proxy, empty uses HTTP_PROXY and HTTPS_PROXY environment variables
+	ProxyURL string
+	// CABundle - path to PEM certificates trusted in addition to the system ones
+	CABundle string
+	// HeadersFile - path to JSON object mapping feed urls or hosts to extra request headers, see LoadHeaders
+	HeadersFile string
+}
+
+// Init builds the shared Client from the config.
+func Init(cfg Config) error {
+	client, err := New(cfg)
+	if err != nil {
+		return err
+	}
+
+	Client = client
+
+	return nil
+}
+
+// New returns a client applying the config to every request.
+func New(cfg Config) (*http.Client, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", cfg.ProxyURL)
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q, use http, https or socks5", proxyURL.Scheme)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CABundle != "" {
+		pool, err := certPool(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	headers, err := LoadHeaders(cfg.HeadersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &configuredTransport{
+			base:            transport,
+			userAgent:       cfg.UserAgent,
+			maxResponseSize: cfg.MaxResponseSize,
+			headers:         headers,
+		},
+	}, nil
+}
+
+// certPool returns system certificates together with the ones from the PEM file.
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
+
+// ExtraHeaders - headers added to requests of specific feeds. Keys with a scheme match
+// urls starting with them, e.g. a single feed, other keys match the host name exactly.
+type ExtraHeaders map[string]map[string]string
+
+// LoadHeaders reads extra headers from the JSON file, empty path means no extra headers.
+func LoadHeaders(path string) (ExtraHeaders, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extra headers file: %w", err)
+	}
+
+	var headers ExtraHeaders
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, fmt.Errorf("failed to parse extra headers file %s: %w", path, err)
+	}
+
+	return headers, nil
+}
+
+// For returns extra headers of the url. Url prefix matches are applied after the host one
+// and longer prefixes after shorter ones, so the most specific match wins.
+func (h ExtraHeaders) For(u *url.URL) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+
+	raw := u.String()
+	matches := []string{strings.ToLower(u.Hostname())}
+
+	for prefix := range h {
+		if strings.Contains(prefix, "://") && strings.HasPrefix(raw, prefix) {
+			matches = append(matches, prefix)
+		}
+	}
+
+	slices.SortStableFunc(matches[1:], func(a, b string) int { return len(a) - len(b) })
+
+	result := make(map[string]string)
+
+	for _, match := range matches {
+		for key, value := range h[match] {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// configuredTransport sets the user agent and extra headers of requests and limits response sizes.
+type configuredTransport struct {
+	base            http.RoundTripper
+	userAgent       string
+	maxResponseSize int64
+	headers         ExtraHeaders
+}
+
+func (t *configuredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	extra := t.headers.For(req.URL)
+
+	if (t.userAgent != "" && req.Header.Get("User-Agent") == "") || len(extra) > 0 {
+		req = req.Clone(req.Context())
+
+		if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+			req.Header.Set("User-Agent", t.userAgent)
+		}
+
+		for key, value := range extra {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || t.maxResponseSize <= 0 {
+		return resp, err
+	}
+
+	if resp.ContentLength > t.maxResponseSize {
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("%s: %w", req.URL.Redacted(), ErrResponseTooLarge)
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxResponseSize}
+
+	return resp, nil
+}
+
+// limitedBody fails with ErrResponseTooLarge instead of silently truncating the body.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	// read one byte past the limit to tell a body of exactly the max size from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+
+	return path
+}
+
+func get(t *testing.T, client *http.Client, url string) (string, error) {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+
+	return string(body), err
+}
+
+func TestClientHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.UserAgent()+"|"+r.Header.Get("Cookie")+"|"+r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	headers := writeFile(t, "headers.json", `{
+		"127.0.0.1": {"Cookie": "host", "Authorization": "host"},
+		"`+srv.URL+`/private": {"Authorization": "short"},
+		"`+srv.URL+`/private/feed": {"Authorization": "long"}
+	}`)
+
+	client, err := New(Config{UserAgent: "RapidFeed/test", HeadersFile: headers})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/public", want: "RapidFeed/test|host|host"},
+		{path: "/private/other", want: "RapidFeed/test|host|short"},
+		{path: "/private/feed.xml", want: "RapidFeed/test|host|long"},
+	}
+
+	for _, tt := range tests {
+		got, err := get(t, client, srv.URL+tt.path)
+		if err != nil || got != tt.want {
+			t.Fatalf("%s: expected %q, got %q, %v", tt.path, tt.want, got, err)
+		}
+	}
+}
+
+func TestClientMaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/declared" {
+			w.Header().Set("Content-Length", "11")
+		} else {
+			// chunked response, its size is unknown in advance
+			w.(http.Flusher).Flush()
+		}
+
+		_, _ = io.WriteString(w, strings.Repeat("x", 11))
+	}))
+	defer srv.Close()
+
+	client, err := New(Config{MaxResponseSize: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{"/declared", "/chunked"} {
+		if _, err := get(t, client, srv.URL+path); !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("%s: expected ErrResponseTooLarge, got %v", path, err)
+		}
+	}
+
+	client, err = New(Config{MaxResponseSize: 11})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if body, err := get(t, client, srv.URL+"/chunked"); err != nil || len(body) != 11 {
+		t.Fatalf("expected response of exactly the max size to be read, got %d bytes, %v", len(body), err)
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "proxied "+r.URL.String())
+	}))
+	defer proxy.Close()
+
+	client, err := New(Config{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := get(t, client, "http://feeds.example.com/rss")
+	if err != nil || body != "proxied http://feeds.example.com/rss" {
+		t.Fatalf("expected request to go through the proxy, got %q, %v", body, err)
+	}
+
+	if _, err := New(Config{ProxyURL: "ftp://proxy:21"}); err == nil {
+		t.Fatal("expected unsupported proxy scheme to be rejected")
+	}
+}
+
+func TestClientCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client, err := New(Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := get(t, client, srv.URL); err == nil {
+		t.Fatal("expected certificate of the test server not to be trusted")
+	}
+
+	bundle := writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	})))
+
+	client, err = New(Config{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if body, err := get(t, client, srv.URL); err != nil || body != "ok" {
+		t.Fatalf("expected certificate from the bundle to be trusted, got %q, %v", body, err)
+	}
+
+	if _, err := New(Config{CABundle: writeFile(t, "empty.pem", "nothing here")}); err == nil {
+		t.Fatal("expected bundle without certificates to be rejected")
+	}
+}
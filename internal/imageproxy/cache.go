@@ -0,0 +1,142 @@
+package imageproxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheDir      = "./image-cache"
+	defaultCacheMaxBytes = 200 << 20
+	cacheFileExt         = ".jpg"
+)
+
+// diskCache keeps re-encoded images as files, the least recently used ones are removed
+// when the total size goes over the limit.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+func newDiskCache(dir string, maxBytes int64) (*diskCache, error) {
+	if dir == "" {
+		dir = defaultCacheDir
+	}
+
+	if maxBytes <= 0 {
+		maxBytes = defaultCacheMaxBytes
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create image cache dir %s: %w", dir, err)
+	}
+
+	return &diskCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key+cacheFileExt)
+}
+
+// get returns the cached image and marks it as recently used.
+func (c *diskCache) get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+
+	return data, true
+}
+
+// put writes the image through a temporary file, so readers never see a partial one, and evicts old entries.
+func (c *diskCache) put(key string, data []byte) error {
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("failed to save cache file: %w", err)
+	}
+
+	return c.evict()
+}
+
+// evict removes the least recently used images until the cache fits its size limit.
+func (c *diskCache) evict() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list image cache: %w", err)
+	}
+
+	type cached struct {
+		path   string
+		size   int64
+		usedAt time.Time
+	}
+
+	files := make([]cached, 0, len(entries))
+
+	var total int64
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheFileExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, cached{path: filepath.Join(c.dir, entry.Name()), size: info.Size(), usedAt: info.ModTime()})
+		total += info.Size()
+	}
+
+	if total <= c.maxBytes {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].usedAt.Before(files[j].usedAt) })
+
+	for _, file := range files {
+		if total <= c.maxBytes {
+			break
+		}
+
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict cached image: %w", err)
+		}
+
+		total -= file.size
+	}
+
+	return nil
+}
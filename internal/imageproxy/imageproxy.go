@@ -0,0 +1,238 @@
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	// decoders of the supported source formats
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Path - route the proxied images are served from
+	Path = "/img"
+
+	defaultMaxWidth = 1024
+	defaultTimeout  = 30 * time.Second
+	jpegQuality     = 80
+
+	// maxSourceSize - remote images larger than that are not downloaded completely
+	maxSourceSize = 15 << 20
+	// maxSourcePixels protects from images which are small on the wire but huge when decoded
+	maxSourcePixels = 50_000_000
+)
+
+var (
+	ErrNotImage = errors.New("remote resource is not a supported image")
+	ErrTooLarge = errors.New("remote image is too large")
+)
+
+// Config - image proxy settings, zero values fall back to defaults
+type Config struct {
+	CacheDir      string
+	CacheMaxBytes int64
+	MaxWidth      int
+	// Key signs proxied urls, so the proxy can't be used to fetch arbitrary urls
+	Key       string
+	Client    *http.Client
+	Timeout   time.Duration
+	UserAgent string
+}
+
+// Proxy downloads remote images, downsizes them to the max width and re-encodes them to baseline JPEG,
+// which every old browser is able to show. Results are kept in a size limited disk cache.
+type Proxy struct {
+	cfg   Config
+	cache *diskCache
+	group singleflight.Group
+}
+
+func New(cfg Config) (*Proxy, error) {
+	if cfg.MaxWidth <= 0 {
+		cfg.MaxWidth = defaultMaxWidth
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	cache, err := newDiskCache(cfg.CacheDir, cfg.CacheMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{cfg: cfg, cache: cache}, nil
+}
+
+// URL returns the proxied address of the remote image, urls other than absolute http(s) are returned as is.
+func (p *Proxy) URL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return raw
+	}
+
+	return Path + "?u=" + url.QueryEscape(raw) + "&s=" + p.sign(raw)
+}
+
+// Verify reports whether the signature was issued by URL for the remote url.
+func (p *Proxy) Verify(raw, signature string) bool {
+	if raw == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(p.sign(raw)), []byte(signature))
+}
+
+func (p *Proxy) sign(raw string) string {
+	mac := hmac.New(sha256.New, []byte(p.cfg.Key))
+	mac.Write([]byte(raw))
+
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Image returns the re-encoded remote image from the cache, downloading it on a miss.
+// Concurrent requests of the same image share a single download, it is bound by the configured timeout only,
+// so a caller going away doesn't fail it for the others. Every caller stops waiting once its own ctx is done.
+func (p *Proxy) Image(ctx context.Context, raw string) ([]byte, error) {
+	key := cacheKey(raw, p.cfg.MaxWidth)
+
+	if data, ok := p.cache.get(key); ok {
+		return data, nil
+	}
+
+	fetchCtx := context.WithoutCancel(ctx)
+
+	results := p.group.DoChan(key, func() (any, error) {
+		data, err := p.fetch(fetchCtx, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.cache.put(key, data); err != nil {
+			slog.Error("failed to cache proxied image", "url", raw, "error", err)
+		}
+
+		return data, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		return result.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Proxy) fetch(ctx context.Context, raw string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", raw, err)
+	}
+
+	if p.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", p.cfg.UserAgent)
+	}
+
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", raw, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Error("failed to close image response body", "url", raw, "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status %s", raw, resp.Status)
+	}
+
+	if resp.ContentLength > maxSourceSize {
+		return nil, ErrTooLarge
+	}
+
+	source, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", raw, err)
+	}
+
+	if len(source) > maxSourceSize {
+		return nil, ErrTooLarge
+	}
+
+	return Reencode(source, p.cfg.MaxWidth)
+}
+
+// Reencode decodes the image, downsizes it to maxWidth keeping the aspect ratio and encodes it to baseline JPEG.
+// Transparent areas become white.
+func Reencode(source []byte, maxWidth int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(source))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if maxWidth > 0 && width > maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+func cacheKey(raw string, maxWidth int) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(maxWidth) + "\n" + raw))
+
+	return hex.EncodeToString(sum[:])
+}
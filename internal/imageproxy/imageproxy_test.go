@@ -0,0 +1,224 @@
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test png: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// isBaselineJPEG checks that the jpeg has a baseline frame header and no progressive one.
+func isBaselineJPEG(data []byte) bool {
+	return bytes.Contains(data, []byte{0xFF, 0xC0}) && !bytes.Contains(data, []byte{0xFF, 0xC2})
+}
+
+func TestReencode(t *testing.T) {
+	out, err := Reencode(testPNG(t, 2000, 500), 800)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !isBaselineJPEG(out) {
+		t.Fatal("expected baseline jpeg")
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+
+	if cfg.Width != 800 || cfg.Height != 200 {
+		t.Fatalf("expected 800x200, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	small, err := Reencode(testPNG(t, 100, 50), 800)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg, _ := jpeg.DecodeConfig(bytes.NewReader(small)); cfg.Width != 100 || cfg.Height != 50 {
+		t.Fatalf("expected small image to keep its size, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	// transparent areas are painted white instead of black
+	img, err := jpeg.Decode(bytes.NewReader(small))
+	if err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+
+	if r, g, b, _ := img.At(50, 40).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Fatalf("expected white background, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	if _, err := Reencode([]byte("<html></html>"), 800); !errors.Is(err, ErrNotImage) {
+		t.Fatalf("expected ErrNotImage, got %v", err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	pngData := testPNG(t, 1600, 800)
+
+	var requests atomic.Int32
+
+	var slowOnce sync.Once
+
+	slowStarted, slowRelease := make(chan struct{}), make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch r.URL.Path {
+		case "/a.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngData)
+		case "/slow.png":
+			slowOnce.Do(func() { close(slowStarted) })
+			<-slowRelease
+			_, _ = w.Write(pngData)
+		case "/page":
+			_, _ = w.Write([]byte("<html>not an image</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	proxy, err := New(Config{CacheDir: t.TempDir(), MaxWidth: 640, Key: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	t.Run("urls are signed", func(t *testing.T) {
+		proxied, err := url.Parse(proxy.URL(srv.URL + "/a.png"))
+		if err != nil || proxied.Path != Path {
+			t.Fatalf("unexpected proxied url %v", proxied)
+		}
+
+		if !proxy.Verify(proxied.Query().Get("u"), proxied.Query().Get("s")) {
+			t.Fatal("expected issued signature to be valid")
+		}
+
+		if proxy.Verify(srv.URL+"/other.png", proxied.Query().Get("s")) {
+			t.Fatal("expected signature of another url to be rejected")
+		}
+
+		if got := proxy.URL("data:image/png;base64,AAAA"); got != "data:image/png;base64,AAAA" {
+			t.Fatalf("expected non http url to be kept, got %q", got)
+		}
+	})
+
+	t.Run("image is re-encoded and cached", func(t *testing.T) {
+		for range 2 {
+			out, err := proxy.Image(context.Background(), srv.URL+"/a.png")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cfg, _ := jpeg.DecodeConfig(bytes.NewReader(out)); cfg.Width != 640 || !isBaselineJPEG(out) {
+				t.Fatalf("expected 640px wide baseline jpeg, got width %d", cfg.Width)
+			}
+		}
+
+		if n := requests.Load(); n != 1 {
+			t.Fatalf("expected a single download, got %d", n)
+		}
+	})
+
+	t.Run("canceled caller doesn't fail the shared download", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+
+		go func() {
+			_, err := proxy.Image(ctx, srv.URL+"/slow.png")
+			first <- err
+		}()
+
+		<-slowStarted
+
+		second := make(chan error, 1)
+
+		go func() {
+			_, err := proxy.Image(context.Background(), srv.URL+"/slow.png")
+			second <- err
+		}()
+
+		cancel()
+
+		if err := <-first; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled caller to stop waiting, got %v", err)
+		}
+
+		close(slowRelease)
+
+		if err := <-second; err != nil {
+			t.Fatalf("expected the other caller to get the image, got %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := proxy.Image(context.Background(), srv.URL+"/page"); !errors.Is(err, ErrNotImage) {
+			t.Fatalf("expected ErrNotImage, got %v", err)
+		}
+
+		if _, err := proxy.Image(context.Background(), srv.URL+"/missing.png"); err == nil {
+			t.Fatal("expected error for missing image")
+		}
+	})
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	cache, err := newDiskCache(t.TempDir(), 25)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	now := time.Now()
+
+	for i, key := range []string{"a", "b"} {
+		if err := cache.put(key, bytes.Repeat([]byte(key), 10)); err != nil {
+			t.Fatalf("failed to put %s: %v", key, err)
+		}
+
+		usedAt := now.Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(cache.path(key), usedAt, usedAt); err != nil {
+			t.Fatalf("failed to set use time of %s: %v", key, err)
+		}
+	}
+
+	if err := cache.put("c", bytes.Repeat([]byte("c"), 10)); err != nil {
+		t.Fatalf("failed to put c: %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if _, ok := cache.get(key); ok != want {
+			t.Fatalf("cached %s = %v, want %v", key, ok, want)
+		}
+	}
+}
@@ -186,7 +186,7 @@ func (t *httpTransport) handleClientMessage(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	response, err := t.HandleMessage(body)
+	response, err := t.handleMessage(body)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Message handling failed: %v", err), http.StatusInternalServerError)
 		return
@@ -198,6 +198,50 @@ func (t *httpTransport) handleClientMessage(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// handleMessage answers prompts/get of feedPrompts itself and leaves everything else to gomcp.
+// Batches are split so the prompts inside them are answered too, responses keep the request order.
+func (t *httpTransport) handleMessage(message []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		if response, ok := handlePromptRequest(message); ok {
+			return response, nil
+		}
+
+		return t.HandleMessage(message)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil || len(batch) == 0 {
+		// malformed and empty batches get gomcp's error response
+		return t.HandleMessage(message)
+	}
+
+	responses := make([]json.RawMessage, 0, len(batch))
+
+	for _, item := range batch {
+		response, ok := handlePromptRequest(item)
+		if !ok {
+			var err error
+
+			response, err = t.HandleMessage(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// notifications have no response
+		if len(bytes.TrimSpace(response)) > 0 {
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(responses)
+}
+
 func (t *httpTransport) handleSSEStream(w http.ResponseWriter, r *http.Request) {
 	accept := r.Header.Get("Accept")
 	if !strings.Contains(accept, "text/event-stream") {
@@ -318,7 +362,7 @@ func injectToken(message []byte, token string) ([]byte, error) {
 
 func injectTokenToObject(obj map[string]interface{}, token string) map[string]interface{} {
 	method, _ := obj["method"].(string)
-	if method != "tools/call" {
+	if method != "tools/call" && method != "resources/read" && method != "prompts/get" {
 		return obj
 	}
 
@@ -328,29 +372,42 @@ func injectTokenToObject(obj map[string]interface{}, token string) map[string]in
 		obj["params"] = params
 	}
 
+	// resource reads have no arguments, the token is read from params by resourceToken
+	if method == "resources/read" {
+		if !hasToken(params) {
+			params["token"] = token
+		}
+
+		return obj
+	}
+
 	args, ok := params["arguments"].(map[string]interface{})
 	if !ok {
 		args = make(map[string]interface{})
 		params["arguments"] = args
 	}
 
-	if existing, ok := args["token"]; ok {
-		switch v := existing.(type) {
-		case string:
-			if strings.TrimSpace(v) != "" {
-				return obj
-			}
-		default:
-			if v != nil {
-				return obj
-			}
-		}
+	if !hasToken(args) {
+		args["token"] = token
 	}
 
-	args["token"] = token
 	return obj
 }
 
+func hasToken(args map[string]interface{}) bool {
+	existing, ok := args["token"]
+	if !ok {
+		return false
+	}
+
+	switch v := existing.(type) {
+	case string:
+		return strings.TrimSpace(v) != ""
+	default:
+		return v != nil
+	}
+}
+
 func contextWithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), timeout)
 }
@@ -0,0 +1,277 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/localrivet/gomcp/mcp"
+	gomcp "github.com/localrivet/gomcp/server"
+)
+
+const (
+	// promptItems - max number of posts put into a prompt, the newest ones are kept
+	promptItems = 100
+	// promptDescriptionRunes - longer post descriptions are cut in prompts
+	promptDescriptionRunes = 500
+)
+
+// feedPrompt - prompt built from the user's posts. Template is registered with gomcp, so the prompt
+// is listed with its {{placeholders}} as arguments, and serves as the instruction part of the message.
+type feedPrompt struct {
+	name        string
+	description string
+	template    string
+	// items selects posts for the prompt arguments, true means some posts were left out
+	items func(userID int, args map[string]interface{}) ([]models.FeedItem, bool, error)
+}
+
+var feedPrompts = []feedPrompt{
+	{
+		name:        "daily_briefing",
+		description: "Briefing of posts published in the user's feeds during the last 24 hours.",
+		template: "Give me a briefing of the posts published in my RSS feeds during the last 24 hours. " +
+			"Group related posts into topics, start with the most important news, summarize every topic " +
+			"in a few sentences and link the original posts.",
+		items: func(userID int, _ map[string]interface{}) ([]models.FeedItem, bool, error) {
+			start, end, err := relativeRange("last 24 hours", time.Now())
+			if err != nil {
+				return nil, false, err
+			}
+
+			return promptFeedItems(userID, models.FeedItemsFilter{From: start, To: end}, nil)
+		},
+	},
+	{
+		name:        "topic_digest",
+		description: "Digest of the last 7 days of posts from the user's feeds having the tag.",
+		template: "Write a digest of the posts tagged {{tag}} in my RSS feeds from the last 7 days. " +
+			"Describe the main themes and notable posts and link the original posts.",
+		items: func(userID int, args map[string]interface{}) ([]models.FeedItem, bool, error) {
+			tag := strings.TrimSpace(promptArg(args, "tag"))
+			if tag == "" {
+				return nil, false, invalidPromptArgs(fmt.Errorf("tag is required"))
+			}
+
+			start, end, err := relativeRange("last 7 days", time.Now())
+			if err != nil {
+				return nil, false, err
+			}
+
+			return promptFeedItems(userID, models.FeedItemsFilter{From: start, To: end}, []string{tag})
+		},
+	},
+	{
+		name:        "what_i_missed",
+		description: "Unread posts from the user's feeds since a date (YYYY-MM-DD or RFC 3339) or a relative period like \"3 days\" or \"this week\".",
+		template: "Tell me what I missed in my RSS feeds since {{since}}. These are posts I haven't read yet: " +
+			"point out the ones worth reading in full, briefly summarize the rest by topic and link the original posts.",
+		items: func(userID int, args map[string]interface{}) ([]models.FeedItem, bool, error) {
+			since, err := parseSince(promptArg(args, "since"), time.Now())
+			if err != nil {
+				return nil, false, invalidPromptArgs(err)
+			}
+
+			return promptFeedItems(userID, models.FeedItemsFilter{From: since, HideRead: true}, nil)
+		},
+	},
+}
+
+// JSON-RPC error codes of prompts/get answered by handlePromptRequest
+const (
+	rpcInvalidParams = -32602
+	rpcInternalError = -32603
+)
+
+// promptArgsError - prompt arguments are missing or invalid, answered with rpcInvalidParams
+type promptArgsError struct {
+	err error
+}
+
+func (e promptArgsError) Error() string {
+	return e.err.Error()
+}
+
+func (e promptArgsError) Unwrap() error {
+	return e.err
+}
+
+func invalidPromptArgs(err error) error {
+	return promptArgsError{err: err}
+}
+
+func registerPrompts(srv gomcp.Server) {
+	for _, prompt := range feedPrompts {
+		srv.Prompt(prompt.name, prompt.description, gomcp.User(prompt.template))
+	}
+}
+
+// handlePromptRequest answers a single prompts/get of feedPrompts with messages including the user's posts,
+// gomcp can only render static templates. False means the message is left for gomcp to handle,
+// batches are split by the transport, see handleMessage.
+func handlePromptRequest(message []byte) ([]byte, bool) {
+	var request struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+		Params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"params"`
+	}
+
+	if err := json.Unmarshal(message, &request); err != nil || request.Method != "prompts/get" {
+		return nil, false
+	}
+
+	for _, prompt := range feedPrompts {
+		if prompt.name != request.Params.Name {
+			continue
+		}
+
+		result, err := prompt.render(request.Params.Arguments)
+		if err != nil {
+			code := rpcInternalError
+			if errors.As(err, new(promptArgsError)) {
+				code = rpcInvalidParams
+			}
+
+			response, marshalErr := mcp.NewErrorResponse(request.ID, code, err.Error(), nil).Marshal()
+			return response, marshalErr == nil
+		}
+
+		response, err := mcp.NewSuccessResponse(request.ID, result).Marshal()
+		return response, err == nil
+	}
+
+	return nil, false
+}
+
+func (p feedPrompt) render(args map[string]interface{}) (*gomcp.PromptGetResponse, error) {
+	token := promptArg(args, "token")
+	if strings.TrimSpace(token) == "" {
+		return nil, invalidPromptArgs(fmt.Errorf("token is required (use X-MCP-Token header)"))
+	}
+
+	userID, err := userIDFromToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	instruction, err := gomcp.SubstituteVariables(p.template, args)
+	if err != nil {
+		return nil, invalidPromptArgs(err)
+	}
+
+	items, truncated, err := p.items(userID, args)
+	if err != nil {
+		return nil, err
+	}
+
+	text := instruction + "\n\n" + formatPromptItems(items, truncated)
+
+	return gomcp.NewPromptGetResponse(p.description, []gomcp.PromptMessage{{
+		Role:    "user",
+		Content: gomcp.PromptContent{Type: gomcp.ContentTypeText, Text: text},
+	}}), nil
+}
+
+// promptFeedItems returns the newest promptItems posts of the user's feeds having any of the tags,
+// true means there are more posts than that.
+func promptFeedItems(userID int, filter models.FeedItemsFilter, tags []string) ([]models.FeedItem, bool, error) {
+	userFeeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	filter.FeedURLs = filterFeedURLs(userFeeds, tags, nil)
+	if len(filter.FeedURLs) == 0 {
+		if len(tags) > 0 {
+			return nil, false, invalidPromptArgs(fmt.Errorf("no feeds tagged %q", strings.Join(tags, ", ")))
+		}
+
+		return nil, false, nil
+	}
+
+	items, next, err := db.GetUserFeedItemsAfter(userID, filter, nil, promptItems)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return items, next != nil, nil
+}
+
+// parseSince accepts a date, a timestamp or a relative period, bare "3 days" reads as "last 3 days".
+func parseSince(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("since is required")
+	}
+
+	if since, err := parseDateArg(&raw, false, now.Location()); err == nil {
+		return since, nil
+	}
+
+	since, _, err := relativeRange(raw, now)
+	if err != nil {
+		fields := strings.Fields(raw)
+		if _, numErr := strconv.Atoi(fields[0]); numErr == nil && len(fields) == 2 {
+			since, _, err = relativeRange("last "+raw, now)
+		}
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q, use YYYY-MM-DD, RFC 3339 timestamp or a period like 3 days or this week", raw)
+	}
+
+	return since, nil
+}
+
+// formatPromptItems lists posts as markdown for the language model
+func formatPromptItems(items []models.FeedItem, truncated bool) string {
+	if len(items) == 0 {
+		return "There are no posts for this request."
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Posts (%d):\n", len(items))
+
+	for _, item := range items {
+		date := item.Date
+		if t, err := time.Parse(time.RFC3339, item.Date); err == nil {
+			date = t.In(time.Local).Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(&b, "\n- **%s** (%s, %s)\n  %s\n", item.Title, item.Source, date, item.Link)
+
+		if description := truncateRunes(strings.TrimSpace(item.Description), promptDescriptionRunes); description != "" {
+			fmt.Fprintf(&b, "  %s\n", description)
+		}
+	}
+
+	if truncated {
+		fmt.Fprintf(&b, "\nOnly the newest %d posts are listed, older ones were left out.\n", promptItems)
+	}
+
+	return b.String()
+}
+
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
+
+func promptArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+
+	return value
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	gomcp "github.com/localrivet/gomcp/server"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		since   string
+		want    time.Time
+		wantErr bool
+	}{
+		{since: "2025-03-01", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{since: "2025-03-10T08:00:00Z", want: time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{since: "3 days", want: now.AddDate(0, 0, -3)},
+		{since: "last 2 hours", want: now.Add(-2 * time.Hour)},
+		{since: "yesterday", want: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{since: "this week", want: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{since: "This Month", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{since: "past 1 week", want: now.AddDate(0, 0, -7)},
+		{since: "3 fortnights", wantErr: true},
+		{since: "last this week", wantErr: true},
+		{since: "", wantErr: true},
+		{since: "a while", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.since, func(t *testing.T) {
+			got, err := parseSince(tc.since, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+
+				return
+			}
+
+			if err != nil || !got.Equal(tc.want) {
+				t.Fatalf("expected %v, got %v %v", tc.want, got, err)
+			}
+		})
+	}
+}
+
+func TestHandleClientMessage_Prompts(t *testing.T) {
+	setupTestDB(t)
+
+	userID := addTestUser(t, "alice")
+
+	if err := db.UpsertUserToken(userID, "alice-token"); err != nil {
+		t.Fatalf("failed to set token: %v", err)
+	}
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := db.AddUserFeed(userID, "A", feedURL, "go"); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	_, err := db.SaveFeedItem(models.IncomingFeedItem{
+		FeedURL: feedURL,
+		GUID:    "1",
+		Title:   "Fresh post",
+		Link:    feedURL + "/1",
+		Date:    time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("failed to save item: %v", err)
+	}
+
+	srv := gomcp.NewServer("test")
+	registerPrompts(srv)
+
+	transport := newHTTPTransport("")
+	transport.SetMessageHandler(func(message []byte) ([]byte, error) {
+		return gomcp.HandleMessage(srv.GetServer(), message)
+	})
+
+	send := func(t *testing.T, body string) []byte {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, defaultMCPEndpoint, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-MCP-Token", "alice-token")
+
+		rec := httptest.NewRecorder()
+		transport.handleClientMessage(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+
+		return rec.Body.Bytes()
+	}
+
+	type rpcResponse struct {
+		ID     int `json:"id"`
+		Result struct {
+			Messages []struct {
+				Content struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		} `json:"result"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var response rpcResponse
+
+		body := send(t, `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"what_i_missed","arguments":{"since":"a while"}}}`)
+		if err := json.Unmarshal(body, &response); err != nil {
+			t.Fatalf("failed to decode response %s: %v", body, err)
+		}
+
+		if response.Error == nil || response.Error.Code != rpcInvalidParams {
+			t.Fatalf("expected invalid params error, got %s", body)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		body := send(t, `[
+			{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"daily_briefing"}},
+			{"jsonrpc":"2.0","method":"notifications/initialized"},
+			{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"topic_digest","arguments":{}}},
+			{"jsonrpc":"2.0","id":3,"method":"ping"}
+		]`)
+
+		var responses []rpcResponse
+		if err := json.Unmarshal(body, &responses); err != nil {
+			t.Fatalf("failed to decode response %s: %v", body, err)
+		}
+
+		if len(responses) != 3 || responses[0].ID != 1 || responses[1].ID != 2 || responses[2].ID != 3 {
+			t.Fatalf("expected responses to requests 1, 2 and 3, got %s", body)
+		}
+
+		if len(responses[0].Result.Messages) != 1 || !strings.Contains(responses[0].Result.Messages[0].Content.Text, "Fresh post") {
+			t.Fatalf("expected briefing with the user's posts, got %s", body)
+		}
+
+		if responses[1].Error == nil || responses[1].Error.Code != rpcInvalidParams {
+			t.Fatalf("expected invalid params error for missing tag, got %s", body)
+		}
+
+		if responses[2].Error != nil {
+			t.Fatalf("expected ping to succeed, got %s", body)
+		}
+	})
+}
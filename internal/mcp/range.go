@@ -0,0 +1,305 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// IANA timezones of the timezone argument must resolve on hosts without system tzdata too
+	_ "time/tzdata"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+// defaultRangeLimit - page size of feeds_range when the limit is omitted
+const defaultRangeLimit = 100
+
+type rangeArgs struct {
+	Token    *string   `json:"token" description:"User MCP access token (optional if provided via X-MCP-Token header)"`
+	Range    *string   `json:"range" description:"Relative period: today, yesterday, this week, this month, last N minutes/hours/days/weeks/months. Use instead of start and end"`
+	Start    *string   `json:"start" description:"Only items published at or after this time, RFC 3339 timestamp or YYYY-MM-DD"`
+	End      *string   `json:"end" description:"Only items published before this time, RFC 3339 timestamp or YYYY-MM-DD (the whole day is included), now by default"`
+	Timezone *string   `json:"timezone" description:"IANA timezone of dates, relative periods and returned item dates, e.g. Europe/Berlin. Server timezone by default"`
+	Tags     *[]string `json:"tags" description:"Only items of feeds having any of these tags"`
+	Sources  *[]string `json:"sources" description:"Only items of these feeds, matched by feed title or url"`
+	Limit    *int      `json:"limit" description:"Max number of items per page, 100 by default"`
+	Cursor   *string   `json:"cursor" description:"next_cursor of the previous page to continue from, pass the other arguments unchanged"`
+}
+
+type rangeItem struct {
+	feedItem
+	ID int `json:"id"`
+}
+
+type rangeResponse struct {
+	Items       []rangeItem `json:"items"`
+	Count       int         `json:"count"`
+	Start       string      `json:"start"`
+	End         string      `json:"end"`
+	Timezone    string      `json:"timezone"`
+	Limit       int         `json:"limit"`
+	NextCursor  string      `json:"next_cursor,omitempty"`
+	GeneratedAt string      `json:"generated_at"`
+}
+
+// fetchUserFeedItemsByRange returns a page of the user's items within the requested period, newest first.
+func fetchUserFeedItemsByRange(userID int, args *rangeArgs) (rangeResponse, error) {
+	response := rangeResponse{
+		Items:       []rangeItem{},
+		Limit:       defaultRangeLimit,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+
+	if args.Limit != nil {
+		response.Limit = *args.Limit
+	}
+
+	if response.Limit <= 0 {
+		return response, fmt.Errorf("limit must be a positive integer")
+	}
+	if response.Limit > maxMCPItems {
+		return response, fmt.Errorf("limit must be <= %d", maxMCPItems)
+	}
+
+	var (
+		page       *rangeCursor
+		cursor     *models.FeedItemsCursor
+		start, end time.Time
+		loc        = time.Local
+		err        error
+	)
+
+	if args.Cursor != nil && strings.TrimSpace(*args.Cursor) != "" {
+		page, err = decodeCursor(strings.TrimSpace(*args.Cursor))
+		if err != nil {
+			return response, err
+		}
+
+		if page.Query != rangeQueryKey(args) {
+			return response, fmt.Errorf("cursor belongs to a query with other arguments, pass them unchanged or start over without cursor")
+		}
+
+		// the period is resolved once on the first page, otherwise relative ranges would move between pages
+		loc, err = time.LoadLocation(page.Timezone)
+		if err != nil {
+			return response, fmt.Errorf("invalid cursor")
+		}
+
+		start, end = page.Start.In(loc), page.End.In(loc)
+		cursor = &models.FeedItemsCursor{Date: page.Date, ID: page.ID}
+	} else {
+		if args.Timezone != nil && strings.TrimSpace(*args.Timezone) != "" {
+			loc, err = time.LoadLocation(strings.TrimSpace(*args.Timezone))
+			if err != nil {
+				return response, fmt.Errorf("invalid timezone: %w", err)
+			}
+		}
+
+		start, end, err = periodFromArgs(args, time.Now().In(loc))
+		if err != nil {
+			return response, err
+		}
+	}
+
+	response.Timezone = loc.String()
+	response.Start = start.Format(time.RFC3339)
+	response.End = end.Format(time.RFC3339)
+
+	userFeeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return response, err
+	}
+
+	feedURLs := filterFeedURLs(userFeeds, derefStrings(args.Tags), derefStrings(args.Sources))
+	if len(feedURLs) == 0 {
+		return response, nil
+	}
+
+	filter := models.FeedItemsFilter{FeedURLs: feedURLs, From: start, To: end}
+
+	items, next, err := db.GetUserFeedItemsAfter(userID, filter, cursor, response.Limit)
+	if err != nil {
+		return response, err
+	}
+
+	for _, item := range items {
+		date := item.Date
+		if t, err := time.Parse(time.RFC3339, item.Date); err == nil {
+			date = t.In(loc).Format(time.RFC3339)
+		}
+
+		response.Items = append(response.Items, rangeItem{
+			feedItem: feedItem{
+				Title:       item.Title,
+				Link:        item.Link,
+				Date:        date,
+				Source:      item.Source,
+				Description: item.Description,
+			},
+			ID: item.ID,
+		})
+	}
+
+	response.Count = len(response.Items)
+
+	if next != nil {
+		response.NextCursor = encodeCursor(rangeCursor{
+			ID:       next.ID,
+			Date:     next.Date,
+			Start:    start,
+			End:      end,
+			Timezone: loc.String(),
+			Query:    rangeQueryKey(args),
+		})
+	}
+
+	return response, nil
+}
+
+// periodFromArgs resolves either the relative range or start and end, now carries the timezone.
+func periodFromArgs(args *rangeArgs, now time.Time) (time.Time, time.Time, error) {
+	hasRange := args.Range != nil && strings.TrimSpace(*args.Range) != ""
+	hasStart := args.Start != nil && strings.TrimSpace(*args.Start) != ""
+	hasEnd := args.End != nil && strings.TrimSpace(*args.End) != ""
+
+	if hasRange {
+		if hasStart || hasEnd {
+			return time.Time{}, time.Time{}, fmt.Errorf("use either range or start and end")
+		}
+
+		return relativeRange(*args.Range, now)
+	}
+
+	if !hasStart {
+		return time.Time{}, time.Time{}, fmt.Errorf("range or start is required")
+	}
+
+	start, err := parseDateArg(args.Start, false, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start: %w", err)
+	}
+
+	end := now
+	if hasEnd {
+		end, err = parseDateArg(args.End, true, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start must be before end")
+	}
+
+	return start, end, nil
+}
+
+// relativeRange turns expressions like "today" or "last 7 days" into [start, end) relative to now,
+// calendar periods follow the timezone of now and weeks start on Monday.
+func relativeRange(expr string, now time.Time) (time.Time, time.Time, error) {
+	expr = strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch expr {
+	case "today":
+		return startOfToday, startOfToday.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return startOfToday.AddDate(0, 0, -1), startOfToday, nil
+	case "this week":
+		start := startOfToday.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7), nil
+	case "this month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) < 2 || len(fields) > 3 || (fields[0] != "last" && fields[0] != "past") {
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown range %q, use today, yesterday, this week, this month or last N days", expr)
+	}
+
+	count := 1
+	if len(fields) == 3 {
+		var err error
+
+		count, err = strconv.Atoi(fields[1])
+		if err != nil || count <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid number in range %q", expr)
+		}
+	}
+
+	switch strings.TrimSuffix(fields[len(fields)-1], "s") {
+	case "minute":
+		return now.Add(-time.Duration(count) * time.Minute), now, nil
+	case "hour":
+		return now.Add(-time.Duration(count) * time.Hour), now, nil
+	case "day":
+		return now.AddDate(0, 0, -count), now, nil
+	case "week":
+		return now.AddDate(0, 0, -7*count), now, nil
+	case "month":
+		return now.AddDate(0, -count, 0), now, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown unit in range %q, use minutes, hours, days, weeks or months", expr)
+	}
+}
+
+// rangeCursor - next_cursor of feeds_range: position of the last returned item, the period resolved
+// for the first page and the key of the arguments it was resolved from.
+type rangeCursor struct {
+	ID       int       `json:"id"`
+	Date     string    `json:"date"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Timezone string    `json:"tz"`
+	Query    string    `json:"q"`
+}
+
+// rangeQueryKey identifies the arguments selecting items, the cursor and the page size are left out.
+func rangeQueryKey(args *rangeArgs) string {
+	key, _ := json.Marshal([]interface{}{
+		trimmedArg(args.Range), trimmedArg(args.Start), trimmedArg(args.End), trimmedArg(args.Timezone),
+		derefStrings(args.Tags), derefStrings(args.Sources),
+	})
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func trimmedArg(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*value)
+}
+
+// encodeCursor makes an opaque next_cursor
+func encodeCursor(cursor rangeCursor) string {
+	raw, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string) (*rangeCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor rangeCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	if cursor.ID <= 0 || cursor.Date == "" || cursor.Query == "" || cursor.Start.IsZero() || cursor.End.IsZero() {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &cursor, nil
+}
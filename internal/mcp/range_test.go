@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func TestRelativeRange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	// Wednesday
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, berlin)
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, berlin) }
+
+	tests := []struct {
+		expr       string
+		start, end time.Time
+		wantErr    bool
+	}{
+		{expr: "today", start: day(3, 12), end: day(3, 13)},
+		{expr: " Yesterday ", start: day(3, 11), end: day(3, 12)},
+		{expr: "this week", start: day(3, 10), end: day(3, 17)},
+		{expr: "this month", start: day(3, 1), end: day(4, 1)},
+		{expr: "last 7 days", start: now.AddDate(0, 0, -7), end: now},
+		{expr: "past 2 hours", start: now.Add(-2 * time.Hour), end: now},
+		{expr: "last week", start: now.AddDate(0, 0, -7), end: now},
+		{expr: "last 0 days", wantErr: true},
+		{expr: "last 3 fortnights", wantErr: true},
+		{expr: "tomorrow", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			start, end, err := relativeRange(tc.expr, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v - %v", start, end)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !start.Equal(tc.start) || !end.Equal(tc.end) {
+				t.Fatalf("expected %v - %v, got %v - %v", tc.start, tc.end, start, end)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := rangeCursor{
+		ID:       42,
+		Date:     "2025-03-12T15:30:00+01:00",
+		Start:    time.Date(2025, 3, 5, 15, 30, 0, 0, time.UTC),
+		End:      time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC),
+		Timezone: "Europe/Berlin",
+		Query:    "key",
+	}
+
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	if err != nil || *decoded != cursor {
+		t.Fatalf("expected %+v, got %+v %v", cursor, decoded, err)
+	}
+
+	if _, err := decodeCursor("not a cursor"); err == nil {
+		t.Fatalf("expected error for malformed cursor")
+	}
+}
+
+func TestFetchUserFeedItemsByRange_Cursor(t *testing.T) {
+	setupTestDB(t)
+
+	userID := addTestUser(t, "alice")
+
+	const feedURL = "https://a.example.com/rss"
+
+	if err := db.AddUserFeed(userID, "A", feedURL, "go"); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i, date := range []time.Time{now.Add(-time.Hour), now.Add(-2 * time.Hour), now.AddDate(0, 0, -2).Add(time.Hour)} {
+		_, err := db.SaveFeedItem(models.IncomingFeedItem{
+			FeedURL: feedURL,
+			GUID:    strconv.Itoa(i),
+			Title:   "post " + strconv.Itoa(i),
+			Date:    date.Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("failed to save item: %v", err)
+		}
+	}
+
+	rangeExpr, limit := "last 1 days", 1
+	args := &rangeArgs{Range: &rangeExpr, Limit: &limit}
+
+	first, err := fetchUserFeedItemsByRange(userID, args)
+	if err != nil || first.Count != 1 || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v %v", first, err)
+	}
+
+	t.Run("period of the first page is kept", func(t *testing.T) {
+		page, err := decodeCursor(first.NextCursor)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// as if the next page was requested a day later, the window must not follow the clock
+		page.Start, page.End = page.Start.AddDate(0, 0, -1), page.End.AddDate(0, 0, -1)
+		shifted := encodeCursor(*page)
+		args := &rangeArgs{Range: &rangeExpr, Limit: &limit, Cursor: &shifted}
+
+		next, err := fetchUserFeedItemsByRange(userID, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if next.Start != page.Start.In(time.Local).Format(time.RFC3339) || next.Count != 1 || next.Items[0].Title != "post 2" {
+			t.Fatalf("expected the cursor period to be used, got %+v", next)
+		}
+	})
+
+	t.Run("cursor of other arguments is rejected", func(t *testing.T) {
+		otherRange := "last 2 days"
+		tags := []string{"go"}
+
+		for name, args := range map[string]*rangeArgs{
+			"range": {Range: &otherRange, Cursor: &first.NextCursor},
+			"tags":  {Range: &rangeExpr, Tags: &tags, Cursor: &first.NextCursor},
+		} {
+			t.Run(name, func(t *testing.T) {
+				if _, err := fetchUserFeedItemsByRange(userID, args); err == nil {
+					t.Fatalf("expected error for cursor of other arguments")
+				}
+			})
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		next, err := fetchUserFeedItemsByRange(userID, &rangeArgs{Range: &rangeExpr, Limit: &limit, Cursor: &first.NextCursor})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if next.Start != first.Start || next.End != first.End || next.Count != 1 || next.Items[0].Title != "post 1" || next.NextCursor != "" {
+			t.Fatalf("unexpected second page: %+v", next)
+		}
+	})
+}
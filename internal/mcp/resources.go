@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/sanitizer"
+	gomcp "github.com/localrivet/gomcp/server"
+)
+
+const (
+	resourceScheme = "rapidfeed://"
+	feedsResource  = resourceScheme + "feeds"
+	tagResource    = resourceScheme + "tag/{tag}"
+	itemResource   = resourceScheme + "item/{id}"
+)
+
+// tagResourceItems - number of latest items listed by a tag resource
+const tagResourceItems = 100
+
+type tagResponse struct {
+	Tag           string          `json:"tag"`
+	Subscriptions []subscription  `json:"subscriptions"`
+	Items         []resourceEntry `json:"items"`
+	Count         int             `json:"count"`
+	GeneratedAt   string          `json:"generated_at"`
+}
+
+// resourceEntry - item listed by a resource, its full content is available at URI
+type resourceEntry struct {
+	feedItem
+	URI string `json:"uri"`
+}
+
+type itemResponse struct {
+	URI         string `json:"uri"`
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	Date        string `json:"date"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+	// ContentHTML - extracted full article if the feed has full articles turned on, content shipped in the feed otherwise.
+	// Sanitized the same way as on the item page, relative urls are resolved against the item link.
+	ContentHTML string `json:"content_html"`
+	IsRead      bool   `json:"is_read"`
+	IsStarred   bool   `json:"is_starred"`
+}
+
+func registerResources(srv gomcp.Server) {
+	srv.Resource(feedsResource, "Feeds the user is subscribed to with their tags, unread counts and fetch health.", func(ctx *gomcp.Context, args interface{}) (interface{}, error) {
+		userID, err := userIDFromToken(resourceToken(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions, err := listSubscriptions(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return subscriptions, nil
+	})
+
+	srv.Resource(tagResource, "Feeds having the tag and their latest posts, each post links its item resource.", func(ctx *gomcp.Context, args interface{}) (interface{}, error) {
+		userID, err := userIDFromToken(resourceToken(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		tag := strings.TrimSpace(resourceParam(args, "tag"))
+		if tag == "" {
+			return nil, fmt.Errorf("tag is required")
+		}
+
+		response, err := tagResourceItemsList(userID, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		return response, nil
+	})
+
+	srv.Resource(itemResource, "A single post with its full stored content.", func(ctx *gomcp.Context, args interface{}) (interface{}, error) {
+		userID, err := userIDFromToken(resourceToken(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := itemResourceResponse(userID, resourceParam(args, "id"))
+		if err != nil {
+			return nil, err
+		}
+
+		return item, nil
+	})
+}
+
+func tagResourceItemsList(userID int, tag string) (tagResponse, error) {
+	response := tagResponse{
+		Tag:           tag,
+		Subscriptions: []subscription{},
+		Items:         []resourceEntry{},
+		GeneratedAt:   time.Now().Format(time.RFC3339),
+	}
+
+	feeds, err := userFeedsWithUnread(userID)
+	if err != nil {
+		return response, err
+	}
+
+	feedURLs := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		if feedHasAnyTag(feed, []string{tag}) {
+			feedURLs = append(feedURLs, feed.FeedURL)
+			response.Subscriptions = append(response.Subscriptions, toSubscription(feed))
+		}
+	}
+
+	if len(feedURLs) == 0 {
+		return response, fmt.Errorf("no feeds tagged %q", tag)
+	}
+
+	items, _, err := db.GetUserFeedItemsAfter(userID, models.FeedItemsFilter{FeedURLs: feedURLs}, nil, tagResourceItems)
+	if err != nil {
+		return response, err
+	}
+
+	for _, item := range items {
+		response.Items = append(response.Items, resourceEntry{
+			feedItem: feedItem{
+				Title:       item.Title,
+				Link:        item.Link,
+				Date:        item.Date,
+				Source:      item.Source,
+				Description: item.Description,
+			},
+			URI: itemURI(item.ID),
+		})
+	}
+
+	response.Count = len(response.Items)
+
+	return response, nil
+}
+
+// itemResourceResponse returns the item of the user's feeds or starred items, other items are not found.
+func itemResourceResponse(userID int, rawID string) (itemResponse, error) {
+	itemID, err := strconv.Atoi(rawID)
+	if err != nil {
+		return itemResponse{}, fmt.Errorf("item id must be a number")
+	}
+
+	item, err := db.GetUserFeedItem(userID, itemID)
+	if err != nil {
+		if errors.Is(err, db.ErrItemNotFound) {
+			return itemResponse{}, fmt.Errorf("item %d not found", itemID)
+		}
+
+		return itemResponse{}, err
+	}
+
+	return itemResponse{
+		URI:         itemURI(item.ID),
+		ID:          item.ID,
+		Title:       item.Title,
+		Link:        item.Link,
+		Date:        item.Date,
+		Source:      item.Source,
+		Description: item.Description,
+		ContentHTML: sanitizer.Sanitize(item.Content, item.Link, nil),
+		IsRead:      item.IsRead,
+		IsStarred:   item.IsStarred,
+	}, nil
+}
+
+func itemURI(id int) string {
+	return resourceScheme + "item/" + strconv.Itoa(id)
+}
+
+// resourceToken returns the token the transport put into resources/read params, see injectTokenToObject
+func resourceToken(ctx *gomcp.Context) string {
+	if ctx == nil || ctx.Request == nil || ctx.Request.Params == nil {
+		return ""
+	}
+
+	var params struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(params.Token)
+}
+
+// resourceParam returns a parameter of the resource template matched by the uri
+func resourceParam(args interface{}, name string) string {
+	params, ok := args.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	value, _ := params[name].(string)
+
+	return value
+}
@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	gomcp "github.com/localrivet/gomcp/server"
+)
+
+func TestResourceToken(t *testing.T) {
+	tests := map[string]struct {
+		ctx  *gomcp.Context
+		want string
+	}{
+		"no context":  {ctx: nil, want: ""},
+		"no params":   {ctx: &gomcp.Context{Request: &gomcp.Request{}}, want: ""},
+		"no token":    {ctx: &gomcp.Context{Request: &gomcp.Request{Params: json.RawMessage(`{"uri":"rapidfeed://feeds"}`)}}, want: ""},
+		"bad params":  {ctx: &gomcp.Context{Request: &gomcp.Request{Params: json.RawMessage(`[1]`)}}, want: ""},
+		"token":       {ctx: &gomcp.Context{Request: &gomcp.Request{Params: json.RawMessage(`{"uri":"rapidfeed://feeds","token":" secret "}`)}}, want: "secret"},
+		"wrong token": {ctx: &gomcp.Context{Request: &gomcp.Request{Params: json.RawMessage(`{"token":42}`)}}, want: ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := resourceToken(tc.ctx); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// setupResourceFeeds subscribes alice to go and rust feeds and bob to another go feed,
+// every feed has a single item. Returns user ids and item ids by feed url.
+func setupResourceFeeds(t *testing.T) (alice, bob int, items map[string]int) {
+	t.Helper()
+
+	setupTestDB(t)
+
+	alice = addTestUser(t, "alice")
+	bob = addTestUser(t, "bob")
+	items = make(map[string]int)
+
+	for _, sub := range []struct {
+		userID           int
+		title, url, tags string
+	}{
+		{userID: alice, title: "Go blog", url: "https://go.example.com/rss", tags: "Go, news"},
+		{userID: alice, title: "Rust blog", url: "https://rust.example.com/rss", tags: "rust"},
+		{userID: bob, title: "Bob's Go", url: "https://bob.example.com/rss", tags: "go"},
+	} {
+		if err := db.AddUserFeed(sub.userID, sub.title, sub.url, sub.tags); err != nil {
+			t.Fatalf("failed to add feed: %v", err)
+		}
+
+		_, err := db.SaveFeedItem(models.IncomingFeedItem{
+			FeedURL: sub.url,
+			GUID:    "1",
+			Title:   "post of " + sub.title,
+			Link:    sub.url + "/1",
+			Date:    time.Now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("failed to save item: %v", err)
+		}
+
+		var id int
+		if err := db.DB.QueryRow(`SELECT id FROM feeds WHERE feed_url = ?`, sub.url).Scan(&id); err != nil {
+			t.Fatalf("failed to get item id: %v", err)
+		}
+
+		items[sub.url] = id
+	}
+
+	return alice, bob, items
+}
+
+func TestTagResource(t *testing.T) {
+	alice, bob, items := setupResourceFeeds(t)
+
+	response, err := tagResourceItemsList(alice, "go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(response.Subscriptions) != 1 || response.Subscriptions[0].Title != "Go blog" || response.Subscriptions[0].UnreadCount != 1 {
+		t.Fatalf("expected only alice's go feed, got %+v", response.Subscriptions)
+	}
+
+	if response.Count != 1 || response.Items[0].Title != "post of Go blog" ||
+		response.Items[0].URI != itemURI(items["https://go.example.com/rss"]) {
+		t.Fatalf("expected only the item of alice's go feed, got %+v", response.Items)
+	}
+
+	response, err = tagResourceItemsList(bob, "go")
+	if err != nil || response.Count != 1 || response.Items[0].Title != "post of Bob's Go" {
+		t.Fatalf("expected only bob's item, got %+v %v", response.Items, err)
+	}
+
+	if _, err := tagResourceItemsList(bob, "rust"); err == nil {
+		t.Fatalf("expected error for tag of another user's feeds")
+	}
+}
+
+func TestItemResource(t *testing.T) {
+	alice, bob, items := setupResourceFeeds(t)
+
+	itemID := items["https://rust.example.com/rss"]
+
+	_, err := db.DB.Exec(`UPDATE feeds SET content = ? WHERE id = ?`,
+		`<p onclick="steal()">Full <a href="/more">article</a></p><script>alert(1)</script>`, itemID)
+	if err != nil {
+		t.Fatalf("failed to set item content: %v", err)
+	}
+
+	item, err := itemResourceResponse(alice, strconv.Itoa(itemID))
+	if err != nil || item.ID != itemID || item.Title != "post of Rust blog" {
+		t.Fatalf("unexpected item: %+v %v", item, err)
+	}
+
+	if strings.Contains(item.ContentHTML, "script") || strings.Contains(item.ContentHTML, "onclick") ||
+		!strings.Contains(item.ContentHTML, `href="https://rust.example.com/more"`) {
+		t.Fatalf("expected sanitized content with resolved links, got %q", item.ContentHTML)
+	}
+
+	if _, err := itemResourceResponse(bob, strconv.Itoa(itemID)); err == nil ||
+		!strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected item of another user's feed to be not found, got %v", err)
+	}
+
+	if _, err := itemResourceResponse(alice, "abc"); err == nil {
+		t.Fatalf("expected error for non-numeric id")
+	}
+}
+
+func TestReadResource(t *testing.T) {
+	alice, _, _ := setupResourceFeeds(t)
+
+	if err := db.UpsertUserToken(alice, "alice-token"); err != nil {
+		t.Fatalf("failed to set token: %v", err)
+	}
+
+	srv := gomcp.NewServer("test")
+	registerResources(srv)
+
+	read := func(t *testing.T, params string) (string, string) {
+		t.Helper()
+
+		raw, err := gomcp.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":`+params+`}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var response struct {
+			Result struct {
+				Contents []struct {
+					Text string `json:"text"`
+				} `json:"contents"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal(raw, &response); err != nil {
+			t.Fatalf("failed to decode response %s: %v", raw, err)
+		}
+
+		if response.Error != nil {
+			return "", response.Error.Message
+		}
+
+		if len(response.Result.Contents) == 0 {
+			t.Fatalf("expected resource contents, got %s", raw)
+		}
+
+		return response.Result.Contents[0].Text, ""
+	}
+
+	text, errMessage := read(t, `{"uri":"rapidfeed://tag/news","token":"alice-token"}`)
+	if errMessage != "" || !strings.Contains(text, "post of Go blog") || strings.Contains(text, "Bob") {
+		t.Fatalf("unexpected tag resource: %q %q", text, errMessage)
+	}
+
+	text, errMessage = read(t, `{"uri":"rapidfeed://feeds"}`)
+	if errMessage == "" {
+		t.Fatalf("expected error without token, got %q", text)
+	}
+}
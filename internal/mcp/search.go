@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+)
+
+// defaultSearchLimit - number of search results returned when the limit is omitted
+const defaultSearchLimit = 20
+
+// markdown emphasis replacing search highlight markers in titles and snippets
+const searchHighlight = "**"
+
+type searchArgs struct {
+	Token   *string   `json:"token" description:"User MCP access token (optional if provided via X-MCP-Token header)"`
+	Query   string    `json:"query" description:"Words to search for, items must contain all of them" required:"true"`
+	From    *string   `json:"from" description:"Only items published at or after this date, YYYY-MM-DD or RFC 3339 timestamp"`
+	To      *string   `json:"to" description:"Only items published before this date, YYYY-MM-DD (the whole day is included) or RFC 3339 timestamp"`
+	Tags    *[]string `json:"tags" description:"Only items of feeds having any of these tags"`
+	Sources *[]string `json:"sources" description:"Only items of these feeds, matched by feed title or url"`
+	Limit   *int      `json:"limit" description:"Max number of results to return, 20 by default"`
+}
+
+type searchItem struct {
+	feedItem
+	ID      int    `json:"id"`
+	Snippet string `json:"snippet"`
+}
+
+type searchResponse struct {
+	Items       []searchItem `json:"items"`
+	Count       int          `json:"count"`
+	Query       string       `json:"query"`
+	From        string       `json:"from,omitempty"`
+	To          string       `json:"to,omitempty"`
+	Limit       int          `json:"limit"`
+	GeneratedAt string       `json:"generated_at"`
+}
+
+// searchUserFeedItems runs full-text search over the user's feeds narrowed down by the args,
+// results are ordered by relevance and matched words are wrapped in searchHighlight.
+func searchUserFeedItems(userID int, args *searchArgs) (searchResponse, error) {
+	response := searchResponse{
+		Items:       []searchItem{},
+		Query:       strings.TrimSpace(args.Query),
+		Limit:       defaultSearchLimit,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+
+	if response.Query == "" {
+		return response, fmt.Errorf("query is required")
+	}
+
+	if args.Limit != nil {
+		response.Limit = *args.Limit
+	}
+
+	if response.Limit <= 0 {
+		return response, fmt.Errorf("limit must be a positive integer")
+	}
+	if response.Limit > maxMCPItems {
+		return response, fmt.Errorf("limit must be <= %d", maxMCPItems)
+	}
+
+	from, err := parseDateArg(args.From, false, time.Local)
+	if err != nil {
+		return response, fmt.Errorf("invalid from: %w", err)
+	}
+
+	to, err := parseDateArg(args.To, true, time.Local)
+	if err != nil {
+		return response, fmt.Errorf("invalid to: %w", err)
+	}
+
+	if !from.IsZero() {
+		response.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		response.To = to.Format(time.RFC3339)
+	}
+
+	userFeeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return response, err
+	}
+
+	feedURLs := filterFeedURLs(userFeeds, derefStrings(args.Tags), derefStrings(args.Sources))
+	if len(feedURLs) == 0 {
+		return response, nil
+	}
+
+	filter := models.FeedItemsFilter{FeedURLs: feedURLs, Query: response.Query, From: from, To: to}
+
+	items, err := db.GetUserFeedItems(userID, filter, response.Limit, 0)
+	if err != nil {
+		return response, err
+	}
+
+	for _, item := range items {
+		response.Items = append(response.Items, searchItem{
+			feedItem: feedItem{
+				Title:       highlightMarkdown(item.TitleHighlight),
+				Link:        item.Link,
+				Date:        item.Date,
+				Source:      item.Source,
+				Description: item.Description,
+			},
+			ID:      item.ID,
+			Snippet: highlightMarkdown(item.Snippet),
+		})
+	}
+
+	response.Count = len(response.Items)
+
+	return response, nil
+}
+
+// parseDateArg parses YYYY-MM-DD in the location or an RFC 3339 timestamp, nil or blank gives zero time.
+// A bare date used as the range end points at the start of the next day so the whole day is included.
+func parseDateArg(raw *string, end bool, loc *time.Location) (time.Time, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return time.Time{}, nil
+	}
+
+	value := strings.TrimSpace(*raw)
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC 3339 timestamp", value)
+	}
+
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return day, nil
+}
+
+// filterFeedURLs returns urls of the feeds having any of the tags and matching any of the sources,
+// empty tags or sources don't restrict. Comparison is case-insensitive.
+func filterFeedURLs(feeds []models.UserFeed, tags, sources []string) []string {
+	urls := make([]string, 0, len(feeds))
+
+	for _, feed := range feeds {
+		if len(tags) > 0 && !feedHasAnyTag(feed, tags) {
+			continue
+		}
+
+		if len(sources) > 0 && !feedMatchesAnySource(feed, sources) {
+			continue
+		}
+
+		urls = append(urls, feed.FeedURL)
+	}
+
+	return urls
+}
+
+func feedHasAnyTag(feed models.UserFeed, tags []string) bool {
+	for _, tag := range utils.SplitTags(feed.Tags) {
+		for _, want := range tags {
+			if strings.EqualFold(tag, strings.TrimSpace(want)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func feedMatchesAnySource(feed models.UserFeed, sources []string) bool {
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+
+		if strings.EqualFold(feed.Title, source) || strings.EqualFold(feed.FeedURL, source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func highlightMarkdown(s string) string {
+	return strings.NewReplacer(models.HighlightStart, searchHighlight, models.HighlightEnd, searchHighlight).Replace(s)
+}
+
+func derefStrings(values *[]string) []string {
+	if values == nil {
+		return nil
+	}
+
+	return *values
+}
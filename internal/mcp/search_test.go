@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in‑memory sqlite: %v", err)
+	}
+
+	conn.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to close test db: %v", err)
+		}
+	})
+
+	db.DB = conn
+
+	if err := db.RunMigrations(db.MigrateUp, 0); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+}
+
+func addTestUser(t *testing.T, username string) int {
+	t.Helper()
+
+	if err := db.RegisterUser(username, "secret"); err != nil {
+		t.Fatalf("failed to register user: %v", err)
+	}
+
+	user, err := db.GetUserInfoByUsername(username)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	return user.ID
+}
+
+// setupSearchFeeds subscribes alice to go and rust feeds and bob to another go feed,
+// every feed has release posts published on March 10 and 11 of the local time.
+func setupSearchFeeds(t *testing.T) (alice, bob int) {
+	t.Helper()
+
+	setupTestDB(t)
+
+	alice = addTestUser(t, "alice")
+	bob = addTestUser(t, "bob")
+
+	for _, sub := range []struct {
+		userID           int
+		title, url, tags string
+	}{
+		{userID: alice, title: "Go blog", url: "https://go.example.com/rss", tags: "Go, news"},
+		{userID: alice, title: "Rust blog", url: "https://rust.example.com/rss", tags: "rust"},
+		{userID: bob, title: "Bob's Go", url: "https://bob.example.com/rss", tags: "go"},
+	} {
+		if err := db.AddUserFeed(sub.userID, sub.title, sub.url, sub.tags); err != nil {
+			t.Fatalf("failed to add feed: %v", err)
+		}
+
+		for i, date := range []time.Time{
+			time.Date(2024, 3, 10, 23, 30, 0, 0, time.Local),
+			time.Date(2024, 3, 11, 0, 30, 0, 0, time.Local),
+		} {
+			_, err := db.SaveFeedItem(models.IncomingFeedItem{
+				FeedURL: sub.url,
+				GUID:    fmt.Sprint(i),
+				Title:   fmt.Sprintf("%s release %d", sub.title, i),
+				Source:  sub.title,
+				Link:    fmt.Sprintf("%s/%d", sub.url, i),
+				Date:    date.UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				t.Fatalf("failed to save item: %v", err)
+			}
+		}
+	}
+
+	return alice, bob
+}
+
+// searchResults returns titles of the found items without highlight markers and their distinct sources, sorted.
+func searchResults(t *testing.T, userID int, args *searchArgs) (titles, sources []string) {
+	t.Helper()
+
+	response, err := searchUserFeedItems(userID, args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, item := range response.Items {
+		titles = append(titles, strings.ReplaceAll(item.Title, searchHighlight, ""))
+
+		if !slices.Contains(sources, item.Source) {
+			sources = append(sources, item.Source)
+		}
+	}
+
+	slices.Sort(titles)
+	slices.Sort(sources)
+
+	return titles, sources
+}
+
+func TestSearchUserFeedItems_Limit(t *testing.T) {
+	alice, _ := setupSearchFeeds(t)
+
+	for name, tc := range map[string]struct {
+		limit   int
+		wantErr string
+	}{
+		"zero":         {limit: 0, wantErr: "positive integer"},
+		"over the cap": {limit: maxMCPItems + 1, wantErr: fmt.Sprintf("limit must be <= %d", maxMCPItems)},
+		"at the cap":   {limit: maxMCPItems},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := searchUserFeedItems(alice, &searchArgs{Query: "release", Limit: &tc.limit})
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	limit := 1
+
+	if titles, _ := searchResults(t, alice, &searchArgs{Query: "release", Limit: &limit}); len(titles) != 1 {
+		t.Fatalf("expected a single result, got %v", titles)
+	}
+}
+
+func TestSearchUserFeedItems_BareDateEnd(t *testing.T) {
+	alice, _ := setupSearchFeeds(t)
+
+	tags := []string{"rust"}
+
+	for name, tc := range map[string]struct {
+		from, to string
+		want     []string
+	}{
+		"whole end day is included": {to: "2024-03-10", want: []string{"Rust blog release 0"}},
+		"start of the day":          {from: "2024-03-11", want: []string{"Rust blog release 1"}},
+		"single day":                {from: "2024-03-11", to: "2024-03-11", want: []string{"Rust blog release 1"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			titles, _ := searchResults(t, alice, &searchArgs{Query: "release", From: &tc.from, To: &tc.to, Tags: &tags})
+			if !slices.Equal(titles, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, titles)
+			}
+		})
+	}
+
+	invalid := "10.03.2024"
+
+	if _, err := searchUserFeedItems(alice, &searchArgs{Query: "release", To: &invalid}); err == nil ||
+		!strings.Contains(err.Error(), "invalid to") {
+		t.Fatalf("expected invalid to error, got %v", err)
+	}
+}
+
+func TestSearchUserFeedItems_Filters(t *testing.T) {
+	alice, _ := setupSearchFeeds(t)
+
+	for name, tc := range map[string]struct {
+		tags, sources []string
+		want          []string
+	}{
+		"no filters":                     {want: []string{"Go blog", "Rust blog"}},
+		"tag matched case-insensitively": {tags: []string{" GO "}, want: []string{"Go blog"}},
+		"any of the tags":                {tags: []string{"rust", "news"}, want: []string{"Go blog", "Rust blog"}},
+		"source by title":                {sources: []string{"rust blog"}, want: []string{"Rust blog"}},
+		"source by url":                  {sources: []string{"https://go.example.com/rss"}, want: []string{"Go blog"}},
+		"tag and source":                 {tags: []string{"news"}, sources: []string{"Rust blog"}},
+		"source of another user":         {sources: []string{"https://bob.example.com/rss"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, sources := searchResults(t, alice, &searchArgs{Query: "release", Tags: &tc.tags, Sources: &tc.sources})
+			if !slices.Equal(sources, tc.want) {
+				t.Fatalf("expected items of %v, got %v", tc.want, sources)
+			}
+		})
+	}
+}
+
+func TestSearchUserFeedItems_TokenScope(t *testing.T) {
+	alice, bob := setupSearchFeeds(t)
+
+	for userID, token := range map[int]string{alice: "alice-token", bob: "bob-token"} {
+		if err := db.UpsertUserToken(userID, token); err != nil {
+			t.Fatalf("failed to set token: %v", err)
+		}
+	}
+
+	for token, want := range map[string][]string{
+		"alice-token": {"Go blog", "Rust blog"},
+		"bob-token":   {"Bob's Go"},
+	} {
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, sources := searchResults(t, userID, &searchArgs{Query: "release"}); !slices.Equal(sources, want) {
+			t.Fatalf("%s: expected items of %v, got %v", token, want, sources)
+		}
+	}
+
+	if _, err := userIDFromToken("unknown-token"); err == nil {
+		t.Fatalf("expected error for unknown token")
+	}
+}
@@ -41,6 +41,8 @@ func Start(addr string) error {
 	srv := gomcp.NewServer("rapidfeed-mcp")
 
 	registerTools(srv)
+	registerResources(srv)
+	registerPrompts(srv)
 
 	transport := newHTTPTransport(addr)
 	srv.GetServer().SetTransport(transport)
@@ -118,6 +120,40 @@ func registerTools(srv gomcp.Server) {
 			GeneratedAt: time.Now().Format(time.RFC3339),
 		}, nil
 	})
+
+	srv.Tool("feeds_search", "Search posts of the user's feeds by words, optionally within a date range, tags and sources. Results are ordered by relevance, matched words are wrapped in ** in titles and snippets.", func(ctx *gomcp.Context, args *searchArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := searchUserFeedItems(userID, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return response, nil
+	})
+
+	srv.Tool("feeds_range", "Return posts of the user's feeds within a relative period like \"last 7 days\" or between start and end, newest first. Pages are limited, pass next_cursor back as cursor to get the next page.", func(ctx *gomcp.Context, args *rangeArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := fetchUserFeedItemsByRange(userID, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return response, nil
+	})
+
+	registerSubscriptionTools(srv)
 }
 
 func tokenFromArgs(args interface{}) string {
@@ -130,6 +166,26 @@ func tokenFromArgs(args interface{}) string {
 		if v != nil && v.Token != nil {
 			return strings.TrimSpace(*v.Token)
 		}
+	case *searchArgs:
+		if v != nil && v.Token != nil {
+			return strings.TrimSpace(*v.Token)
+		}
+	case *rangeArgs:
+		if v != nil && v.Token != nil {
+			return strings.TrimSpace(*v.Token)
+		}
+	case *addSubscriptionArgs:
+		if v != nil && v.Token != nil {
+			return strings.TrimSpace(*v.Token)
+		}
+	case *updateSubscriptionArgs:
+		if v != nil && v.Token != nil {
+			return strings.TrimSpace(*v.Token)
+		}
+	case *removeSubscriptionArgs:
+		if v != nil && v.Token != nil {
+			return strings.TrimSpace(*v.Token)
+		}
 	}
 
 	return ""
@@ -257,15 +313,9 @@ func fetchUserFeedItemsByLimit(userID int, limit int) (items []feedItem, err err
 }
 
 func dayRange(period string) (time.Time, time.Time, error) {
-	now := time.Now().In(time.Local)
-	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
 	switch period {
-	case "today":
-		return startOfToday, startOfToday.AddDate(0, 0, 1), nil
-	case "yesterday":
-		start := startOfToday.AddDate(0, 0, -1)
-		return start, startOfToday, nil
+	case "today", "yesterday":
+		return relativeRange(period, time.Now().In(time.Local))
 	default:
 		return time.Time{}, time.Time{}, fmt.Errorf("invalid period")
 	}
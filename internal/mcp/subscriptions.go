@@ -0,0 +1,321 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/feeder"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	gomcp "github.com/localrivet/gomcp/server"
+)
+
+// probeTimeout - upper bound for downloading and validating a feed being subscribed to
+const probeTimeout = 2 * time.Minute
+
+type addSubscriptionArgs struct {
+	Token *string   `json:"token" description:"User MCP access token (optional if provided via X-MCP-Token header)"`
+	URL   string    `json:"url" description:"Feed url, for a website page the error lists feeds it links to pick from" required:"true"`
+	Title *string   `json:"title" description:"Feed title, the one published in the feed by default"`
+	Tags  *[]string `json:"tags" description:"Tags of the feed"`
+}
+
+type updateSubscriptionArgs struct {
+	Token *string   `json:"token" description:"User MCP access token (optional if provided via X-MCP-Token header)"`
+	ID    int       `json:"id" description:"Subscription id from subscriptions_list" required:"true"`
+	Title *string   `json:"title" description:"New feed title, unchanged if omitted"`
+	Tags  *[]string `json:"tags" description:"New tags replacing the current ones, unchanged if omitted, empty list removes all tags"`
+}
+
+type removeSubscriptionArgs struct {
+	Token *string `json:"token" description:"User MCP access token (optional if provided via X-MCP-Token header)"`
+	ID    int     `json:"id" description:"Subscription id from subscriptions_list" required:"true"`
+}
+
+type subscriptionHealth struct {
+	Status              string `json:"status"`
+	LastFetchAt         string `json:"last_fetch_at,omitempty"`
+	LastSuccessAt       string `json:"last_success_at,omitempty"`
+	LastError           string `json:"last_error,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	ItemCount           int    `json:"item_count"`
+}
+
+type subscription struct {
+	ID          int                `json:"id"`
+	Title       string             `json:"title"`
+	URL         string             `json:"url"`
+	Tags        []string           `json:"tags"`
+	UnreadCount int                `json:"unread_count"`
+	Health      subscriptionHealth `json:"health"`
+}
+
+type subscriptionsResponse struct {
+	Subscriptions []subscription `json:"subscriptions"`
+	Count         int            `json:"count"`
+	GeneratedAt   string         `json:"generated_at"`
+}
+
+func registerSubscriptionTools(srv gomcp.Server) {
+	srv.Tool("subscriptions_list", "List feeds the user is subscribed to with their tags, unread counts and fetch health.", func(ctx *gomcp.Context, args *tokenArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions, err := listSubscriptions(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return subscriptions, nil
+	})
+
+	srv.Tool("subscriptions_add", "Subscribe the user to a feed. The url is downloaded and validated first, its items are available right away.", func(ctx *gomcp.Context, args *addSubscriptionArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		added, err := addSubscription(userID, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return added, nil
+	})
+
+	srv.Tool("subscriptions_update", "Rename a subscription or replace its tags.", func(ctx *gomcp.Context, args *updateSubscriptionArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := updateSubscription(userID, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return updated, nil
+	})
+
+	srv.Tool("subscriptions_remove", "Unsubscribe the user from a feed.", func(ctx *gomcp.Context, args *removeSubscriptionArgs) (interface{}, error) {
+		_ = ctx
+		token := tokenFromArgs(args)
+		userID, err := userIDFromToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		removed, err := removeSubscription(userID, args.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return removed, nil
+	})
+}
+
+// listSubscriptions returns all subscriptions of the user.
+func listSubscriptions(userID int) (subscriptionsResponse, error) {
+	response := subscriptionsResponse{
+		Subscriptions: []subscription{},
+		GeneratedAt:   time.Now().Format(time.RFC3339),
+	}
+
+	feeds, err := userFeedsWithUnread(userID)
+	if err != nil {
+		return response, err
+	}
+
+	for _, feed := range feeds {
+		response.Subscriptions = append(response.Subscriptions, toSubscription(feed))
+	}
+
+	response.Count = len(response.Subscriptions)
+
+	return response, nil
+}
+
+// userFeedsWithUnread returns the user's feeds with unread counts filled in, db.GetUserFeeds leaves them zero.
+func userFeedsWithUnread(userID int) ([]models.UserFeed, error) {
+	feeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unreadCounts, err := db.GetUserUnreadCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range feeds {
+		feeds[i].UnreadCount = unreadCounts[feeds[i].FeedURL]
+	}
+
+	return feeds, nil
+}
+
+// addSubscription validates the url the same way the settings page does and subscribes the user to it.
+func addSubscription(userID int, args *addSubscriptionArgs) (subscription, error) {
+	feedURL := strings.TrimSpace(args.URL)
+	if feedURL == "" {
+		return subscription{}, fmt.Errorf("url is required")
+	}
+
+	feeds, err := db.GetUserFeeds(userID)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	for _, feed := range feeds {
+		if feed.FeedURL == feedURL {
+			return subscription{}, fmt.Errorf("already subscribed to %s, subscription id %d", feedURL, feed.ID)
+		}
+	}
+
+	ctx, cancel := contextWithTimeout(probeTimeout)
+	defer cancel()
+
+	probe, err := feeder.ProbeFeedURL(ctx, feedURL)
+	if err != nil {
+		if errors.Is(err, feeder.ErrNotFeed) {
+			return subscription{}, err
+		}
+
+		return subscription{}, fmt.Errorf("failed to download the feed: %w", err)
+	}
+
+	if !probe.IsFeed {
+		return subscription{}, discoveredFeedsError(feedURL, probe.Feeds)
+	}
+
+	title := probe.Title
+	if args.Title != nil && strings.TrimSpace(*args.Title) != "" {
+		title = strings.TrimSpace(*args.Title)
+	}
+
+	if err := db.AddUserFeed(userID, title, feedURL, utils.NormalizeTags(strings.Join(derefStrings(args.Tags), ","))); err != nil {
+		return subscription{}, err
+	}
+
+	feeder.SaveProbedFeed(ctx, probe)
+
+	feeds, err = userFeedsWithUnread(userID)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	for _, feed := range feeds {
+		if feed.FeedURL == feedURL {
+			return toSubscription(feed), nil
+		}
+	}
+
+	return subscription{}, fmt.Errorf("subscription to %s not found after adding it", feedURL)
+}
+
+// updateSubscription renames the user's subscription or replaces its tags, omitted args are kept.
+func updateSubscription(userID int, args *updateSubscriptionArgs) (subscription, error) {
+	feed, err := findSubscription(userID, args.ID)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	if args.Title != nil {
+		feed.Title = strings.TrimSpace(*args.Title)
+	}
+
+	if args.Tags != nil {
+		feed.Tags = utils.NormalizeTags(strings.Join(*args.Tags, ","))
+	}
+
+	if err := db.UpdateUserFeed(userID, strconv.Itoa(feed.ID), feed.Title, feed.Tags); err != nil {
+		return subscription{}, err
+	}
+
+	return toSubscription(feed), nil
+}
+
+// removeSubscription unsubscribes the user, subscriptions of other users are not found.
+func removeSubscription(userID, feedID int) (subscription, error) {
+	feed, err := findSubscription(userID, feedID)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	if err := db.RemoveUserFeed(userID, strconv.Itoa(feed.ID)); err != nil {
+		return subscription{}, err
+	}
+
+	return toSubscription(feed), nil
+}
+
+// discoveredFeedsError - the url is a website page, the client has to pick one of its feeds
+func discoveredFeedsError(pageURL string, feeds []models.DiscoveredFeed) error {
+	if len(feeds) == 0 {
+		return fmt.Errorf("%s is a web page without any RSS, Atom or JSON feed", pageURL)
+	}
+
+	choices := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		if feed.Title != "" {
+			choices = append(choices, fmt.Sprintf("%s (%s)", feed.URL, feed.Title))
+		} else {
+			choices = append(choices, feed.URL)
+		}
+	}
+
+	return fmt.Errorf("%s is a web page, not a feed, subscribe to one of its feeds: %s", pageURL, strings.Join(choices, ", "))
+}
+
+func findSubscription(userID, feedID int) (models.UserFeed, error) {
+	feeds, err := userFeedsWithUnread(userID)
+	if err != nil {
+		return models.UserFeed{}, err
+	}
+
+	for _, feed := range feeds {
+		if feed.ID == feedID {
+			return feed, nil
+		}
+	}
+
+	return models.UserFeed{}, fmt.Errorf("subscription %d not found", feedID)
+}
+
+func toSubscription(feed models.UserFeed) subscription {
+	return subscription{
+		ID:          feed.ID,
+		Title:       feed.Title,
+		URL:         feed.FeedURL,
+		Tags:        utils.SplitTags(feed.Tags),
+		UnreadCount: feed.UnreadCount,
+		Health: subscriptionHealth{
+			Status:              feed.Health.Status(),
+			LastFetchAt:         formatTime(feed.Health.LastFetchAt),
+			LastSuccessAt:       formatTime(feed.Health.LastSuccessAt),
+			LastError:           feed.Health.LastError,
+			ConsecutiveFailures: feed.Health.ConsecutiveFailures,
+			ItemCount:           feed.Health.ItemCount,
+		},
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
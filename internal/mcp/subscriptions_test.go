@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/db"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+<title>Test feed</title>
+<item>
+<guid>1</guid>
+<title>First post</title>
+<link>https://example.com/1</link>
+<pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+</item>
+</channel>
+</rss>`
+
+func TestAddSubscription(t *testing.T) {
+	setupTestDB(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testRSS))
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("just some notes"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	userID := addTestUser(t, "alice")
+	feedURL := srv.URL + "/feed.xml"
+
+	t.Run("validation", func(t *testing.T) {
+		for name, tc := range map[string]struct{ url, wantErr string }{
+			"blank url":   {url: " ", wantErr: "url is required"},
+			"invalid url": {url: "ftp://example.com/feed", wantErr: "not a valid http(s) url"},
+			"not a feed":  {url: srv.URL + "/notes.txt", wantErr: "not a valid RSS"},
+			"web page":    {url: srv.URL + "/", wantErr: "subscribe to one of its feeds: " + feedURL},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := addSubscription(userID, &addSubscriptionArgs{URL: tc.url})
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+			})
+		}
+	})
+
+	tags := []string{" go ", "News", "", "news", "Go"}
+
+	added, err := addSubscription(userID, &addSubscriptionArgs{URL: feedURL, Tags: &tags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if added.Title != "Test feed" || !slices.Equal(added.Tags, []string{"go", "News"}) {
+		t.Fatalf("unexpected subscription: %+v", added)
+	}
+
+	if added.UnreadCount != 1 {
+		t.Fatalf("expected items of the probed feed to be saved, got %d unread", added.UnreadCount)
+	}
+
+	if _, err := addSubscription(userID, &addSubscriptionArgs{URL: feedURL}); err == nil ||
+		!strings.Contains(err.Error(), "already subscribed") {
+		t.Fatalf("expected duplicate subscription error, got %v", err)
+	}
+}
+
+func TestUpdateAndRemoveSubscription(t *testing.T) {
+	setupTestDB(t)
+
+	alice := addTestUser(t, "alice")
+	bob := addTestUser(t, "bob")
+
+	if err := db.AddUserFeed(alice, "A", "https://a.example.com/rss", "go"); err != nil {
+		t.Fatalf("failed to add feed: %v", err)
+	}
+
+	feeds, err := db.GetUserFeeds(alice)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("failed to get feeds: %v", err)
+	}
+
+	feedID := feeds[0].ID
+
+	t.Run("other user's subscription is rejected", func(t *testing.T) {
+		title := "Stolen"
+
+		if _, err := updateSubscription(bob, &updateSubscriptionArgs{ID: feedID, Title: &title}); err == nil {
+			t.Fatalf("expected error updating another user's subscription")
+		}
+
+		if _, err := removeSubscription(bob, feedID); err == nil {
+			t.Fatalf("expected error removing another user's subscription")
+		}
+
+		feeds, err := db.GetUserFeeds(alice)
+		if err != nil || len(feeds) != 1 || feeds[0].Title != "A" {
+			t.Fatalf("expected subscription to stay unchanged, got %+v %v", feeds, err)
+		}
+	})
+
+	t.Run("tags are normalized and omitted args kept", func(t *testing.T) {
+		tags := []string{"Rust", " rust", "tech, news", ""}
+
+		updated, err := updateSubscription(alice, &updateSubscriptionArgs{ID: feedID, Tags: &tags})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if updated.Title != "A" || !slices.Equal(updated.Tags, []string{"Rust", "tech", "news"}) {
+			t.Fatalf("unexpected subscription: %+v", updated)
+		}
+
+		feeds, err := db.GetUserFeeds(alice)
+		if err != nil || feeds[0].Tags != "Rust, tech, news" {
+			t.Fatalf("unexpected stored tags: %+v %v", feeds, err)
+		}
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		if _, err := removeSubscription(alice, feedID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := removeSubscription(alice, feedID); err == nil {
+			t.Fatalf("expected error removing missing subscription")
+		}
+	})
+}
@@ -1,11 +1,71 @@
 package models
 
+import "time"
+
 type FeedItem struct {
+	ID          int
+	Title       string
+	Link        string
+	Date        string
+	Source      string
+	Description string
+	IsRead      bool
+	IsStarred   bool
+
+	// raw html of the item, filled for the article page only
+	Content string
+
+	// filled for search results only, matched terms are wrapped in HighlightStart and HighlightEnd
+	TitleHighlight string
+	Snippet        string
+}
+
+// markers around matched terms in search highlights, they never occur in normalized feed text
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// IncomingFeedItem - item of a fetched feed ready to be saved. GUID identifies it within the feed.
+// Date is the resolved item date, FirstSeenAt is when it was fetched for the first time.
+// Description is plain text, Content is raw html as published.
+type IncomingFeedItem struct {
+	FeedURL     string
+	GUID        string
 	Title       string
 	Link        string
 	Date        string
 	Source      string
 	Description string
+	Content     string
+	FirstSeenAt time.Time
+}
+
+// FeedItemsFilter narrows down which feed items are selected for a user.
+// Empty FeedURLs means no restriction by feed, which is only meaningful together with StarredOnly.
+// Non-empty Query selects only items matching it by full-text search, ordered by relevance.
+// Non-zero From and To keep items dated within [From, To).
+type FeedItemsFilter struct {
+	FeedURLs    []string
+	HideRead    bool
+	StarredOnly bool
+	Query       string
+	SortBy      string
+	From        time.Time
+	To          time.Time
+}
+
+// timeline sort orders, by item date or by the time it arrived to RapidFeed
+const (
+	SortByPublished = "published"
+	SortByArrival   = "arrival"
+)
+
+// FeedItemsCursor - position in items ordered by date and id descending, Date is as stored, RFC 3339.
+// The next page starts right after the item with this date and id.
+type FeedItemsCursor struct {
+	Date string
+	ID   int
 }
 
 type PaginatedFeedItems struct {
@@ -17,3 +77,110 @@ type PaginatedFeedItems struct {
 	LastUpdate string
 	NextUpdate string
 }
+
+// MaxFetchBackoff - upper bound for the delay between fetches of a failing feed
+const MaxFetchBackoff = 24 * time.Hour
+
+// MaxFeedHintInterval - longer intervals asked by publishers are cut down to that
+const MaxFeedHintInterval = 24 * time.Hour
+
+// FeedHints - how often the publisher wants the feed to be fetched. MinIntervalMinutes comes from
+// RSS ttl, sy:updatePeriod or Cache-Control max-age, whichever is the longest.
+// SkipHours (0-23) and SkipDays are in UTC, as RSS skipHours and skipDays are.
+type FeedHints struct {
+	MinIntervalMinutes int
+	SkipHours          []int
+	SkipDays           []time.Weekday
+}
+
+// skipped reports whether the publisher asked not to fetch the feed at the given time.
+func (h FeedHints) skipped(t time.Time) bool {
+	t = t.UTC()
+
+	for _, hour := range h.SkipHours {
+		if t.Hour() == hour {
+			return true
+		}
+	}
+
+	for _, day := range h.SkipDays {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextAllowed returns t or, if it falls into skipped hours or days, the start of the first hour after them.
+// Hints skipping the whole week are ignored.
+func (h FeedHints) NextAllowed(t time.Time) time.Time {
+	if !h.skipped(t) {
+		return t
+	}
+
+	next := t.Truncate(time.Hour)
+
+	for range 7 * 24 {
+		next = next.Add(time.Hour)
+
+		if !h.skipped(next) {
+			return next
+		}
+	}
+
+	return t
+}
+
+// FeedSchedule describes when a distinct feed url has to be fetched next.
+// Interval is the shortest refresh interval among the feed subscribers.
+type FeedSchedule struct {
+	FeedURL             string
+	IntervalMinutes     int
+	LastFetchAt         time.Time
+	ConsecutiveFailures int
+	RetryAfterAt        time.Time
+	Hints               FeedHints
+}
+
+// NextFetchAt returns the time when the feed becomes due, zero time means "never fetched".
+// The interval is stretched to the publisher hint, if it asks for less frequent fetches.
+// Every consecutive failure doubles the interval up to MaxFetchBackoff,
+// Retry-After asked by the publisher is respected if it is later than that.
+// Hours and days the publisher asked to skip are skipped.
+func (s FeedSchedule) NextFetchAt() time.Time {
+	if s.LastFetchAt.IsZero() {
+		return time.Time{}
+	}
+
+	interval := max(time.Duration(s.IntervalMinutes)*time.Minute,
+		min(time.Duration(s.Hints.MinIntervalMinutes)*time.Minute, MaxFeedHintInterval))
+	delay := interval
+
+	for i := 0; i < s.ConsecutiveFailures && delay < MaxFetchBackoff; i++ {
+		delay *= 2
+	}
+
+	if s.ConsecutiveFailures > 0 {
+		delay = max(min(delay, MaxFetchBackoff), interval)
+	}
+
+	next := s.LastFetchAt.Add(delay)
+	if s.RetryAfterAt.After(next) {
+		return s.RetryAfterAt
+	}
+
+	return s.Hints.NextAllowed(next)
+}
+
+// IsDue reports whether the feed should be fetched at the given moment.
+func (s FeedSchedule) IsDue(now time.Time) bool {
+	return !now.Before(s.NextFetchAt())
+}
+
+// DiscoveredFeed - feed found on a website page, Type is the advertised mime type if any
+type DiscoveredFeed struct {
+	URL   string
+	Title string
+	Type  string
+}
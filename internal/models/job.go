@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// job kinds
+const (
+	JobRefresh = "refresh"
+	JobImport  = "import"
+)
+
+// statuses of jobs and of their feeds, a finished feed is either updated, not modified or failed
+const (
+	JobQueued      = "queued"
+	JobRunning     = "running"
+	JobDone        = "done"
+	JobUpdated     = "updated"
+	JobNotModified = "not_modified"
+	JobFailed      = "failed"
+)
+
+// Job - long operation queued by a user and run in the background, Feeds are fetched one by one.
+type Job struct {
+	ID         int64
+	UserID     int
+	Kind       string
+	Status     string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Feeds      []JobFeed
+}
+
+// JobFeed - progress of a single feed of the job. Title is the user's subscription title, if still subscribed.
+type JobFeed struct {
+	FeedURL    string
+	Title      string
+	Status     string
+	NewItems   int
+	Error      string
+	FinishedAt time.Time
+}
+
+// Finished reports whether the job won't change anymore.
+func (j Job) Finished() bool {
+	return j.Status == JobDone
+}
+
+// Progress returns how many feeds of the job are finished.
+func (j Job) Progress() int {
+	done := 0
+
+	for _, feed := range j.Feeds {
+		if feed.Finished() {
+			done++
+		}
+	}
+
+	return done
+}
+
+// Failed returns how many feeds of the job failed.
+func (j Job) Failed() int {
+	failed := 0
+
+	for _, feed := range j.Feeds {
+		if feed.Status == JobFailed {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// Finished reports whether the feed was fetched, successfully or not.
+func (f JobFeed) Finished() bool {
+	return f.Status == JobUpdated || f.Status == JobNotModified || f.Status == JobFailed
+}
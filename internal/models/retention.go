@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// retention policies, used as names of pruning results
+const (
+	RetentionMaxAge   = "max_age"
+	RetentionMaxItems = "max_items"
+)
+
+// RetentionPolicy - how long feed items are kept, zero limit is disabled.
+type RetentionPolicy struct {
+	MaxAgeDays int
+	MaxItems   int
+}
+
+// FeedRetention - override of the global policy for a single feed url, nil limit keeps the global value.
+type FeedRetention struct {
+	FeedURL    string
+	MaxAgeDays *int
+	MaxItems   *int
+}
+
+// Apply returns the policy effective for the feed.
+func (r FeedRetention) Apply(global RetentionPolicy) RetentionPolicy {
+	policy := global
+
+	if r.MaxAgeDays != nil {
+		policy.MaxAgeDays = *r.MaxAgeDays
+	}
+
+	if r.MaxItems != nil {
+		policy.MaxItems = *r.MaxItems
+	}
+
+	return policy
+}
+
+// PruneResult - rows removed by a policy in a single pruning run, empty FeedURL stands for the global policy.
+type PruneResult struct {
+	Policy  string
+	FeedURL string
+	Removed int64
+}
+
+// PruneStats - results of a policy accumulated over the kept pruning history.
+type PruneStats struct {
+	Policy       string
+	FeedURL      string
+	TotalRemoved int64
+	LastRemoved  int64
+	LastRunAt    time.Time
+}
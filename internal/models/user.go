@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 const (
 	UserRole    = "user"
 	AdminRole   = "admin"
@@ -19,6 +21,56 @@ type UserFeed struct {
 	FeedURL string `json:"feed_url"`
 	Title   string `json:"title"`
 	Tags    string `json:"tags"`
+
+	// FullArticle - download item pages and extract the article, for feeds shipping teasers only
+	FullArticle bool `json:"full_article"`
+
+	// IntervalMinutes - refresh interval of the subscription, nil follows the user's interval, 0 disables autorefresh
+	IntervalMinutes *int `json:"interval_minutes"`
+
+	UnreadCount int        `json:"unread_count"`
+	Health      FeedHealth `json:"health"`
+}
+
+// AutorefreshDisabled reports whether autorefresh is turned off for this subscription.
+func (f UserFeed) AutorefreshDisabled() bool {
+	return f.IntervalMinutes != nil && *f.IntervalMinutes == 0
+}
+
+// FeedHealth - outcome of the latest fetch attempts of a feed url, shared by all its subscribers.
+type FeedHealth struct {
+	LastFetchAt         time.Time `json:"last_fetch_at"`
+	LastSuccessAt       time.Time `json:"last_success_at"`
+	LastStatus          int       `json:"last_status"`
+	LastError           string    `json:"last_error"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	ItemCount           int       `json:"item_count"`
+	RetryAfterAt        time.Time `json:"retry_after_at"`
+	Dead                bool      `json:"dead"`
+
+	// HintIntervalMinutes - how often the publisher wants the feed to be fetched at most, 0 if it doesn't say
+	HintIntervalMinutes int `json:"hint_interval_minutes"`
+}
+
+const (
+	FeedStatusPending = "pending"
+	FeedStatusOK      = "ok"
+	FeedStatusFailing = "failing"
+	FeedStatusDead    = "dead"
+)
+
+// Status returns a short health summary: pending, ok, failing or dead.
+func (h FeedHealth) Status() string {
+	switch {
+	case h.Dead:
+		return FeedStatusDead
+	case h.LastFetchAt.IsZero():
+		return FeedStatusPending
+	case h.ConsecutiveFailures > 0:
+		return FeedStatusFailing
+	default:
+		return FeedStatusOK
+	}
 }
 
 type UserWithFeeds struct {
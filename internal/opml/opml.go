@@ -0,0 +1,170 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/utils"
+	"golang.org/x/net/html/charset"
+)
+
+// Document - OPML 2.0 document, only the parts used for subscription lists
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a feed subscription (has xmlUrl) or a category folder holding nested outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Entry - a single subscription found in an imported document
+type Entry struct {
+	Title string
+	URL   string
+	Tags  []string
+}
+
+// Export builds OPML document of the user feeds. Feeds are grouped into folder outlines
+// by their first tag, the full tags list is kept in the category attribute.
+func Export(title string, feeds []models.UserFeed, created time.Time) ([]byte, error) {
+	doc := Document{
+		Version: "2.0",
+		Head:    Head{Title: title, DateCreated: created.UTC().Format(time.RFC1123Z)},
+	}
+
+	folders := make(map[string]int)
+
+	for _, feed := range feeds {
+		tags := utils.DedupTags(strings.Split(feed.Tags, ","))
+
+		outline := Outline{
+			Text:   feed.Title,
+			Title:  feed.Title,
+			Type:   "rss",
+			XMLURL: feed.FeedURL,
+		}
+
+		if outline.Text == "" {
+			outline.Text = feed.FeedURL
+		}
+
+		if len(tags) == 0 {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+
+			continue
+		}
+
+		outline.Category = strings.Join(tags, ",")
+
+		idx, ok := folders[strings.ToLower(tags[0])]
+		if !ok {
+			idx = len(doc.Body.Outlines)
+			folders[strings.ToLower(tags[0])] = idx
+			doc.Body.Outlines = append(doc.Body.Outlines, Outline{Text: tags[0], Title: tags[0]})
+		}
+
+		doc.Body.Outlines[idx].Outlines = append(doc.Body.Outlines[idx].Outlines, outline)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal opml: %w", err)
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+// Parse reads an OPML document and returns every leaf outline as an entry. Tags of an entry
+// are the titles of its parent folders plus its category attribute. Entries are not validated,
+// leaf outlines without xmlUrl are returned with empty URL.
+func Parse(r io.Reader) ([]Entry, error) {
+	var doc Document
+
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse opml: %w", err)
+	}
+
+	var entries []Entry
+
+	var walk func(outlines []Outline, parents []string)
+	walk = func(outlines []Outline, parents []string) {
+		for _, outline := range outlines {
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+
+			if len(outline.Outlines) > 0 && outline.XMLURL == "" {
+				walk(outline.Outlines, append(parents[:len(parents):len(parents)], title))
+
+				continue
+			}
+
+			tags := append([]string{}, parents...)
+			for _, category := range strings.Split(outline.Category, ",") {
+				// categories are slash-delimited paths, e.g. "/Tech/Go"
+				tags = append(tags, strings.Split(category, "/")...)
+			}
+
+			entries = append(entries, Entry{
+				Title: title,
+				URL:   strings.TrimSpace(outline.XMLURL),
+				Tags:  utils.DedupTags(tags),
+			})
+		}
+	}
+
+	walk(doc.Body.Outlines, nil)
+
+	return entries, nil
+}
+
+// ValidateFeedURL checks that the entry url is an absolute http(s) url.
+func ValidateFeedURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("missing xmlUrl")
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("unsupported url scheme %q", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("url has no host")
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgijGrigoriev/RapidFeed/internal/models"
+)
+
+func TestExportParseRoundTrip(t *testing.T) {
+	feeds := []models.UserFeed{
+		{Title: "Go blog", FeedURL: "https://go.dev/blog/feed.atom", Tags: "go, programming"},
+		{Title: "", FeedURL: "https://example.com/rss"},
+		{Title: "Rust blog", FeedURL: "https://blog.rust-lang.org/feed.xml", Tags: "Programming"},
+	}
+
+	out, err := Export("test", feeds, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := Parse(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Entry{
+		{Title: "Go blog", URL: "https://go.dev/blog/feed.atom", Tags: []string{"go", "programming"}},
+		{Title: "https://example.com/rss", URL: "https://example.com/rss", Tags: []string{}},
+		{Title: "Rust blog", URL: "https://blog.rust-lang.org/feed.xml", Tags: []string{"Programming"}},
+	}
+
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("unexpected entries:\n got %+v\nwant %+v", entries, want)
+	}
+}
+
+func TestParse_NestedFoldersAndCategories(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<opml version="1.0">
+<head><title>Other reader</title></head>
+<body>
+  <outline text="Tech">
+    <outline text="Go">
+      <outline text="Go blog" xmlUrl="https://go.dev/blog/feed.atom" category="/News/Go"/>
+    </outline>
+  </outline>
+  <outline text="Caf&#233;" xmlUrl=" https://cafe.example.com/rss "/>
+  <outline text="Broken"/>
+</body>
+</opml>`
+
+	entries, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Entry{
+		{Title: "Go blog", URL: "https://go.dev/blog/feed.atom", Tags: []string{"Tech", "Go", "News"}},
+		{Title: "Café", URL: "https://cafe.example.com/rss", Tags: []string{}},
+		{Title: "Broken", URL: "", Tags: []string{}},
+	}
+
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("unexpected entries:\n got %+v\nwant %+v", entries, want)
+	}
+}
+
+func TestValidateFeedURL(t *testing.T) {
+	for raw, valid := range map[string]bool{
+		"https://example.com/rss": true,
+		"http://example.com":      true,
+		"":                        false,
+		"ftp://example.com/rss":   false,
+		"/relative/feed.xml":      false,
+		"https://":                false,
+	} {
+		if err := ValidateFeedURL(raw); (err == nil) != valid {
+			t.Errorf("ValidateFeedURL(%q) = %v, want valid=%v", raw, err, valid)
+		}
+	}
+}
+
+func TestParse_NotOPML(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<html><body>nope</body>")); err == nil {
+		t.Fatalf("expected error for non opml document")
+	}
+}
@@ -0,0 +1,92 @@
+package sanitizer
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// policy - allowlist of elements and attributes kept in the item content: text formatting,
+// links, images, lists, tables, quotes and code. Scripts, styles, forms, frames and event handlers are dropped.
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	p.RequireNoReferrerOnFullyQualifiedLinks(true)
+
+	return p
+}
+
+// urlAttrs - attributes holding urls resolved against the item link
+var urlAttrs = map[string]struct{}{
+	"href": {},
+	"src":  {},
+	"cite": {},
+}
+
+// Sanitize makes raw item html safe to render. Relative links and images are resolved against
+// baseURL first, usually the item link, so they keep working on the article page.
+// Non-nil imageURL rewrites the resolved address of every image, e.g. to serve it through the image proxy.
+func Sanitize(raw, baseURL string, imageURL func(string) string) string {
+	if strings.TrimSpace(raw) == "" {
+		return ""
+	}
+
+	return policy.Sanitize(rewriteURLs(raw, baseURL, imageURL))
+}
+
+// rewriteURLs resolves relative url attributes against the base, if it is an absolute url,
+// and passes image sources to imageURL.
+func rewriteURLs(raw, baseURL string, imageURL func(string) string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+
+	if base == nil && imageURL == nil {
+		return raw
+	}
+
+	var out strings.Builder
+
+	tokenizer := html.NewTokenizer(strings.NewReader(raw))
+
+	for {
+		if tokenizer.Next() == html.ErrorToken {
+			// io.EOF or broken markup, the sanitizer deals with whatever is written so far
+			return out.String()
+		}
+
+		token := tokenizer.Token()
+
+		if token.Type == html.StartTagToken || token.Type == html.SelfClosingTagToken {
+			for i, attr := range token.Attr {
+				if _, ok := urlAttrs[attr.Key]; !ok {
+					continue
+				}
+
+				value := strings.TrimSpace(attr.Val)
+
+				if base != nil {
+					if ref, err := url.Parse(value); err == nil {
+						value = base.ResolveReference(ref).String()
+					}
+				}
+
+				if imageURL != nil && token.Data == "img" && attr.Key == "src" {
+					value = imageURL(value)
+				}
+
+				token.Attr[i].Val = value
+			}
+		}
+
+		out.WriteString(token.String())
+	}
+}
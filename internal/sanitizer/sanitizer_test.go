@@ -0,0 +1,85 @@
+package sanitizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	const base = "https://blog.example.com/posts/1"
+
+	tests := []struct {
+		name     string
+		raw      string
+		contains []string
+		missing  []string
+	}{
+		{
+			name:     "formatting and images are kept",
+			raw:      `<p>Hello <b>world</b></p><img src="https://cdn.example.com/a.png" alt="a"><ul><li>one</li></ul>`,
+			contains: []string{"<p>Hello <b>world</b></p>", `<img src="https://cdn.example.com/a.png" alt="a">`, "<li>one</li>"},
+		},
+		{
+			name:    "scripts, styles and handlers are dropped",
+			raw:     `<script>alert(1)</script><style>p{}</style><p onclick="alert(1)" style="color:red">text</p><iframe src="https://evil.example.com"></iframe>`,
+			missing: []string{"script", "alert", "style", "onclick", "iframe"},
+		},
+		{
+			name:    "javascript and data urls are dropped",
+			raw:     `<a href="javascript:alert(1)">x</a><img src="data:image/png;base64,AAAA">`,
+			missing: []string{"javascript:", "data:"},
+		},
+		{
+			name:     "relative urls are resolved against the item link",
+			raw:      `<a href="/about">about</a><img src="img/b.png">`,
+			contains: []string{`href="https://blog.example.com/about"`, `src="https://blog.example.com/posts/img/b.png"`},
+		},
+		{
+			name:     "external links open in a new tab",
+			raw:      `<a href="https://other.example.com/">other</a>`,
+			contains: []string{`target="_blank"`, "noreferrer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sanitize(tt.raw, base, nil)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in %q", want, got)
+				}
+			}
+
+			for _, unwanted := range tt.missing {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("unexpected %q in %q", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeWithoutBase(t *testing.T) {
+	if got := Sanitize(`<a href="/about">about</a>`, "", nil); !strings.Contains(got, `href="/about"`) {
+		t.Fatalf("expected relative link to be kept as is, got %q", got)
+	}
+
+	if got := Sanitize("  ", "https://blog.example.com/", nil); got != "" {
+		t.Fatalf("expected empty content, got %q", got)
+	}
+}
+
+func TestSanitizeImageURL(t *testing.T) {
+	proxied := func(raw string) string { return "/img?u=" + raw }
+
+	got := Sanitize(`<img src="/a.png"><a href="/b.png">link</a>`, "https://blog.example.com/", proxied)
+
+	if !strings.Contains(got, `src="/img?u=https://blog.example.com/a.png"`) {
+		t.Fatalf("expected image to be rewritten, got %q", got)
+	}
+
+	if !strings.Contains(got, `href="https://blog.example.com/b.png"`) {
+		t.Fatalf("expected link to stay direct, got %q", got)
+	}
+}
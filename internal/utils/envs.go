@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -11,6 +14,26 @@ var (
 	SecretKey       string
 	RegisterAllowed bool
 	DBPath          string
+
+	FetchConcurrency     int
+	FetchHostConcurrency int
+	FetchTimeout         time.Duration
+	FetchUserAgent       string
+	FetchMaxResponseMB   int
+	FetchProxy           string
+	FetchCABundle        string
+	FetchHeadersFile     string
+
+	FeedDeadAfterFailures int
+
+	RetentionMaxAgeDays      int
+	RetentionMaxItemsPerFeed int
+	PruneInterval            time.Duration
+
+	ImageProxyEnabled  bool
+	ImageProxyMaxWidth int
+	ImageCacheDir      string
+	ImageCacheMaxMB    int
 )
 
 func GetStringEnv(key, fallback string) string {
@@ -28,3 +51,18 @@ func GetBoolEnv(key string, fallback bool) bool {
 
 	return fallback
 }
+
+func GetIntEnv(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			slog.Error("invalid integer env value, using default", "key", key, "value", value, "default", fallback)
+
+			return fallback
+		}
+
+		return parsed
+	}
+
+	return fallback
+}
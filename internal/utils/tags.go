@@ -0,0 +1,47 @@
+package utils
+
+import "strings"
+
+// NormalizeTags drops blank and case-insensitive duplicate tags of the comma-separated list
+// and joins the rest the way user_feeds.category stores them.
+func NormalizeTags(rawTags string) string {
+	return strings.Join(DedupTags(strings.Split(rawTags, ",")), ", ")
+}
+
+// DedupTags trims the tags and drops blank and case-insensitive duplicate ones, the first spelling is kept.
+func DedupTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	cleaned := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		key := strings.ToLower(tag)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		cleaned = append(cleaned, tag)
+	}
+
+	return cleaned
+}
+
+// SplitTags returns non-blank tags of the comma-separated list.
+func SplitTags(tags string) []string {
+	parts := strings.Split(tags, ",")
+	result := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		tag := strings.TrimSpace(part)
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
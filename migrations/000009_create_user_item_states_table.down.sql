@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_item_states_item;
+DROP TABLE IF EXISTS user_item_states;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_item_states (
+    user_id INTEGER NOT NULL,
+    item_id INTEGER NOT NULL,
+    is_read INTEGER NOT NULL DEFAULT 0,
+    read_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, item_id),
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(item_id) REFERENCES feeds(id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_item_states_item ON user_item_states(item_id);
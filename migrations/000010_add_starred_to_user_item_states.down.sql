@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_feeds_keep_starred;
+DROP INDEX IF EXISTS idx_user_item_states_starred;
+ALTER TABLE user_item_states DROP COLUMN starred_at;
+ALTER TABLE user_item_states DROP COLUMN starred;
@@ -0,0 +1,10 @@
+ALTER TABLE user_item_states ADD COLUMN starred INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_item_states ADD COLUMN starred_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_user_item_states_starred ON user_item_states(user_id, starred);
+-- starred items must survive any cleanup of the feeds table
+CREATE TRIGGER IF NOT EXISTS trg_feeds_keep_starred
+BEFORE DELETE ON feeds
+WHEN EXISTS (SELECT 1 FROM user_item_states WHERE item_id = OLD.id AND starred = 1)
+BEGIN
+    SELECT RAISE(IGNORE);
+END;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS feed_states (
+    feed_url TEXT PRIMARY KEY,
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1 @@
+ALTER TABLE feed_states DROP COLUMN last_fetch_at;
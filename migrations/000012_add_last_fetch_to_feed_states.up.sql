@@ -0,0 +1 @@
+ALTER TABLE feed_states ADD COLUMN last_fetch_at INTEGER;
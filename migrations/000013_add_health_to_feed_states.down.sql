@@ -0,0 +1,5 @@
+ALTER TABLE feed_states DROP COLUMN item_count;
+ALTER TABLE feed_states DROP COLUMN consecutive_failures;
+ALTER TABLE feed_states DROP COLUMN last_error;
+ALTER TABLE feed_states DROP COLUMN last_status;
+ALTER TABLE feed_states DROP COLUMN last_success_at;
@@ -0,0 +1,5 @@
+ALTER TABLE feed_states ADD COLUMN last_success_at INTEGER;
+ALTER TABLE feed_states ADD COLUMN last_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feed_states ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE feed_states ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feed_states ADD COLUMN item_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE feed_states DROP COLUMN dead;
+ALTER TABLE feed_states DROP COLUMN retry_after_at;
@@ -0,0 +1,2 @@
+ALTER TABLE feed_states ADD COLUMN retry_after_at INTEGER;
+ALTER TABLE feed_states ADD COLUMN dead INTEGER NOT NULL DEFAULT 0;
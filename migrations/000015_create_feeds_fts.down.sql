@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_feeds_fts_update;
+DROP TRIGGER IF EXISTS trg_feeds_fts_delete;
+DROP TRIGGER IF EXISTS trg_feeds_fts_insert;
+DROP TABLE IF EXISTS feeds_fts;
@@ -0,0 +1,29 @@
+-- full-text index over item titles and descriptions, the text itself stays in feeds
+CREATE VIRTUAL TABLE IF NOT EXISTS feeds_fts USING fts5(
+    title,
+    description,
+    content = 'feeds',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO feeds_fts(feeds_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS trg_feeds_fts_insert
+AFTER INSERT ON feeds
+BEGIN
+    INSERT INTO feeds_fts(rowid, title, description) VALUES (NEW.id, NEW.title, NEW.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_feeds_fts_delete
+AFTER DELETE ON feeds
+BEGIN
+    INSERT INTO feeds_fts(feeds_fts, rowid, title, description) VALUES ('delete', OLD.id, OLD.title, OLD.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_feeds_fts_update
+AFTER UPDATE OF title, description ON feeds
+BEGIN
+    INSERT INTO feeds_fts(feeds_fts, rowid, title, description) VALUES ('delete', OLD.id, OLD.title, OLD.description);
+    INSERT INTO feeds_fts(rowid, title, description) VALUES (NEW.id, NEW.title, NEW.description);
+END;
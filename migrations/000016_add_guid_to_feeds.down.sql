@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_feeds_feed_url_guid;
+ALTER TABLE feeds DROP COLUMN guid;
@@ -0,0 +1,3 @@
+-- stable item identity within a feed, existing rows get it on the next fetch by matching their link
+ALTER TABLE feeds ADD COLUMN guid TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feeds_feed_url_guid ON feeds(feed_url, guid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_feeds_first_seen_at;
+ALTER TABLE feeds DROP COLUMN first_seen_at;
@@ -0,0 +1,4 @@
+-- arrival time of the item, existing rows only know their resolved date
+ALTER TABLE feeds ADD COLUMN first_seen_at INTEGER;
+UPDATE feeds SET first_seen_at = COALESCE(CAST(strftime('%s', date) AS INTEGER), CAST(strftime('%s', 'now') AS INTEGER));
+CREATE INDEX IF NOT EXISTS idx_feeds_first_seen_at ON feeds(first_seen_at);
@@ -0,0 +1,5 @@
+CREATE TABLE user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    timeline_sort TEXT NOT NULL DEFAULT 'published',
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
@@ -0,0 +1 @@
+-- no-op: publisher offsets of the dates are not kept
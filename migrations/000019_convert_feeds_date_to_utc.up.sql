@@ -0,0 +1,3 @@
+-- item dates were stored with the publisher offset, the timeline sorts them as strings
+UPDATE feeds SET date = strftime('%Y-%m-%dT%H:%M:%SZ', date)
+WHERE strftime('%Y-%m-%dT%H:%M:%SZ', date) IS NOT NULL AND date != strftime('%Y-%m-%dT%H:%M:%SZ', date);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_prune_runs_run_at;
+DROP TABLE IF EXISTS prune_runs;
+DROP TABLE IF EXISTS feed_retention;
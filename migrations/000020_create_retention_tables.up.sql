@@ -0,0 +1,18 @@
+-- per-feed overrides of the global retention policy, NULL keeps the global value, 0 disables the limit
+CREATE TABLE IF NOT EXISTS feed_retention (
+    feed_url TEXT PRIMARY KEY,
+    max_age_days INTEGER,
+    max_items INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- rows removed by every policy on every pruning run, feed_url is empty for the global policy
+CREATE TABLE IF NOT EXISTS prune_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_at INTEGER NOT NULL,
+    policy TEXT NOT NULL,
+    feed_url TEXT NOT NULL DEFAULT '',
+    removed INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_prune_runs_run_at ON prune_runs(run_at);
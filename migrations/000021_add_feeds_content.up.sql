@@ -0,0 +1,2 @@
+-- raw html of the item (content:encoded or description), sanitized when rendered
+ALTER TABLE feeds ADD COLUMN content TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE feeds DROP COLUMN full_content_fetched_at;
+ALTER TABLE feeds DROP COLUMN full_content;
+ALTER TABLE user_feeds DROP COLUMN full_article;
@@ -0,0 +1,5 @@
+-- per subscription opt-in to download item pages and extract the article from them
+ALTER TABLE user_feeds ADD COLUMN full_article INTEGER NOT NULL DEFAULT 0;
+-- extracted article html, full_content_fetched_at is set after every attempt, failed ones included
+ALTER TABLE feeds ADD COLUMN full_content TEXT;
+ALTER TABLE feeds ADD COLUMN full_content_fetched_at INTEGER;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS job_feeds;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,24 @@
+-- background jobs queued by users: manual refreshes, OPML imports
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    created_at INTEGER NOT NULL,
+    started_at INTEGER,
+    finished_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+CREATE INDEX IF NOT EXISTS idx_jobs_user_id ON jobs(user_id);
+
+-- progress of every feed of a job, error is set for failed ones
+CREATE TABLE IF NOT EXISTS job_feeds (
+    job_id INTEGER NOT NULL REFERENCES jobs(id),
+    feed_url TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    new_items INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    finished_at INTEGER,
+    PRIMARY KEY (job_id, feed_url)
+);
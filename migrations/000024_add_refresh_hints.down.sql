@@ -0,0 +1,4 @@
+ALTER TABLE feed_states DROP COLUMN skip_days;
+ALTER TABLE feed_states DROP COLUMN skip_hours;
+ALTER TABLE feed_states DROP COLUMN hint_interval_minutes;
+ALTER TABLE user_feeds DROP COLUMN interval_minutes;
@@ -0,0 +1,6 @@
+-- per subscription refresh interval, NULL follows the user's interval, 0 disables autorefresh of the subscription
+ALTER TABLE user_feeds ADD COLUMN interval_minutes INTEGER;
+-- publisher hints from RSS ttl, sy:updatePeriod and Cache-Control max-age, skips are comma separated UTC hours and weekdays
+ALTER TABLE feed_states ADD COLUMN hint_interval_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feed_states ADD COLUMN skip_hours TEXT NOT NULL DEFAULT '';
+ALTER TABLE feed_states ADD COLUMN skip_days TEXT NOT NULL DEFAULT '';